@@ -0,0 +1,188 @@
+package apicheck
+
+import (
+	"fmt"
+	"github.com/deweysasser/changetool/perf"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rs/zerolog/log"
+	"go/types"
+	"golang.org/x/exp/apidiff"
+	"golang.org/x/tools/go/packages"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Report is the result of comparing the exported API of two revisions
+type Report struct {
+	// Incompatible lists the changes which break users of the API
+	Incompatible []string
+	// Compatible lists the additions to the API
+	Compatible []string
+}
+
+// Check compares the exported go API of the packages at the base commit with those at the head commit
+func Check(r *repo.Repository, base, head plumbing.Hash) (Report, error) {
+	defer perf.Timer("Checking go API compatibility").Stop()
+
+	oldAPI, err := loadCommit(r, base)
+	if err != nil {
+		return Report{}, err
+	}
+
+	newAPI, err := loadCommit(r, head)
+	if err != nil {
+		return Report{}, err
+	}
+
+	return Compare(oldAPI, newAPI), nil
+}
+
+// Compare reports the differences between two sets of packages, keyed by package path.  Packages which failed to load
+// (nil in either set) are not compared.
+func Compare(oldAPI, newAPI map[string]*types.Package) Report {
+	var report Report
+
+	for path, oldPkg := range oldAPI {
+		newPkg, found := newAPI[path]
+		switch {
+		case oldPkg == nil || (found && newPkg == nil):
+			log.Warn().Str("package", path).Msg("Not comparing package which failed to load")
+			continue
+		case !found:
+			report.Incompatible = append(report.Incompatible, fmt.Sprintf("%s: package removed", path))
+			continue
+		}
+
+		for _, change := range apidiff.Changes(oldPkg, newPkg).Changes {
+			message := fmt.Sprintf("%s: %s", path, change.Message)
+			if change.Compatible {
+				report.Compatible = append(report.Compatible, message)
+			} else {
+				report.Incompatible = append(report.Incompatible, message)
+			}
+		}
+	}
+
+	for path, newPkg := range newAPI {
+		if _, found := oldAPI[path]; !found && newPkg != nil {
+			report.Compatible = append(report.Compatible, fmt.Sprintf("%s: package added", path))
+		}
+	}
+
+	sort.Strings(report.Incompatible)
+	sort.Strings(report.Compatible)
+
+	return report
+}
+
+// Load loads the exported packages of the go module in dir, keyed by package path.  Main and internal packages are not
+// part of the API and are skipped.  Packages which fail to load are nil, so that Compare does not take them as removed.
+func Load(dir string) (map[string]*types.Package, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedDeps | packages.NeedImports,
+		Dir:  dir,
+	}, "./...")
+	if err != nil {
+		return nil, err
+	}
+
+	api := make(map[string]*types.Package)
+
+	for _, pkg := range pkgs {
+		if pkg.Name == "main" || isInternal(pkg.PkgPath) {
+			continue
+		}
+
+		if len(pkg.Errors) > 0 {
+			log.Warn().
+				Str("package", pkg.PkgPath).
+				Str("error", pkg.Errors[0].Error()).
+				Msg("Package failed to load")
+			api[pkg.PkgPath] = nil
+			continue
+		}
+
+		api[pkg.PkgPath] = pkg.Types
+	}
+
+	return api, nil
+}
+
+func isInternal(pkgPath string) bool {
+	return strings.HasSuffix(pkgPath, "/internal") ||
+		strings.Contains(pkgPath, "/internal/") ||
+		strings.HasPrefix(pkgPath, "internal/")
+}
+
+// loadCommit checks out the tree of the commit into a temporary directory and loads its API
+func loadCommit(r *repo.Repository, hash plumbing.Hash) (map[string]*types.Package, error) {
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "changetool-api-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	log.Debug().
+		Str("hash", hash.String()[:6]).
+		Str("dir", dir).
+		Msg("Checking out tree")
+
+	if err = Extract(tree, dir); err != nil {
+		return nil, err
+	}
+
+	return Load(dir)
+}
+
+// Extract writes the regular files of the tree beneath dir
+func Extract(tree *object.Tree, dir string) error {
+	return tree.Files().ForEach(func(f *object.File) error {
+		if f.Mode != filemode.Regular && f.Mode != filemode.Executable {
+			return nil
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+			return err
+		}
+
+		reader, err := f.Reader()
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = reader.Close()
+		}()
+
+		// #nosec G304
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+
+		if _, err = io.Copy(out, reader); err != nil {
+			_ = out.Close()
+			return err
+		}
+
+		return out.Close()
+	})
+}
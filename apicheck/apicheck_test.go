@@ -0,0 +1,54 @@
+package apicheck
+
+import (
+	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/test_framework"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	r1, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r1.Run([]test_framework.GitOperation{
+		{
+			Message: "feat: initial commit",
+			Contents: map[string]string{
+				"go.mod":                  "module example.com/m\n\ngo 1.17\n",
+				"pkg/pkg.go":              "package pkg\n\nfunc Keep() {}\n\nfunc Remove() {}\n",
+				"internal/inner/inner.go": "package inner\n\nfunc Hidden() {}\n",
+				"main.go":                 "package main\n\nfunc main() {}\n",
+				"broken/broken.go":        "package broken\n\nfunc Broken() {}\n",
+			},
+		},
+		{Tag: "v1.0.0"},
+		{
+			Message: "refactor: rework the API",
+			Contents: map[string]string{
+				"pkg/pkg.go":              "package pkg\n\nfunc Keep() {}\n\nfunc Added() {}\n",
+				"internal/inner/inner.go": "package inner\n",
+				"extra/extra.go":          "package extra\n",
+				// a package which fails to load is neither removed nor changed
+				"broken/broken.go": "package broken\n\nfunc Broken() { undefined() }\n",
+			},
+		},
+	}))
+
+	r, _ := repo.FromRepository(r1.Repository, nil)
+
+	head, err := r.Head()
+	must(t, err)
+
+	report, err := Check(r, r.TagMap()["v1.0.0"], head.Hash())
+	must(t, err)
+
+	assert.Equal(t, []string{"example.com/m/pkg: Remove: removed"}, report.Incompatible)
+	assert.Equal(t, []string{"example.com/m/extra: package added", "example.com/m/pkg: Added: added"}, report.Compatible)
+}
+
+func must(t *testing.T, err error) {
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/deweysasser/changetool/perf"
 	"github.com/deweysasser/changetool/repo"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/rs/zerolog/log"
@@ -14,7 +15,28 @@ import (
 // ChangeSet represents the list of all changes in a commit stream (presumably from a base tag)
 type ChangeSet struct {
 	BreakingChanges []string
-	Commits         map[TypeTag][]string
+	// Additions are changes which require at least a minor version bump, such as additions to an API
+	Additions []string
+	Commits   map[TypeTag][]string
+	// Count is the number of (non-merge) commits examined
+	Count int
+	// Entries records each change in the order found
+	Entries []Entry
+}
+
+// Entry describes a single change and how it was classified
+type Entry struct {
+	// Hash is the commit making the change, or the zero hash for changes not found in commits
+	Hash     plumbing.Hash
+	Type     TypeTag
+	Scope    string
+	Breaking bool
+	// Guessed is true if the type was not given in the commit message
+	Guessed bool
+	// Summary is the first line of the message, without the type
+	Summary string
+	// ReleaseAs is the version given by a "Release-As:" footer in the commit message, if any
+	ReleaseAs string
 }
 
 // NewChangeSet creates a new, empty change set
@@ -26,6 +48,17 @@ func (c *ChangeSet) addBreaking(message string) {
 	c.BreakingChanges = append(c.BreakingChanges, message)
 }
 
+// AddFinding records a change which was not found in a commit message, such as a difference in a go API.  Breaking
+// findings are breaking changes, all others are additions.
+func (c *ChangeSet) AddFinding(tt TypeTag, message string, breaking bool) {
+	if breaking {
+		c.addBreaking(message)
+	} else {
+		c.Additions = append(c.Additions, message)
+	}
+	c.Entries = append(c.Entries, Entry{Type: tt, Breaking: breaking, Summary: message})
+}
+
 // TODO:  use the section (middle argument)
 func (c *ChangeSet) addCommit(tt TypeTag, _ string, message string) {
 	c.Commits[tt] = append(c.Commits[tt], message)
@@ -43,8 +76,72 @@ func DefaultGuess(tag TypeTag) CommitTypeGuesser {
 
 var commitType = regexp.MustCompile(`([a-zA-Z_][a-zA-Z_0-9]*)(\(([a-zA-Z_][a-zA-Z_0-9]*)\))?(!)?: *`)
 
+var releaseAsFooter = regexp.MustCompile(`(?mi)^Release-As: *(\S+) *$`)
+
+// ReleaseAs returns the version given by the most recent "Release-As:" footer among the changes, and its entry
+func (c *ChangeSet) ReleaseAs() (string, *Entry) {
+	for i := range c.Entries {
+		if c.Entries[i].ReleaseAs != "" {
+			return c.Entries[i].ReleaseAs, &c.Entries[i]
+		}
+	}
+	return "", nil
+}
+
+// AddCommit records the change made by the commit, classifying it by its conventional commit type or, failing that,
+// by the guess.  Merge commits are ignored.
+func (c *ChangeSet) AddCommit(commit *object.Commit, guess CommitTypeGuesser) {
+	if len(commit.ParentHashes) > 1 {
+		return
+	}
+
+	c.Count++
+	message := commit.Message
+	re := commitType.FindStringSubmatch(message)
+	section := ""
+	if len(re) > 3 {
+		section = re[3]
+	}
+
+	var tt TypeTag
+	guessed := false
+
+	if len(re) > 1 {
+		tt = TypeTag(re[1])
+		message = message[len(re[0]):]
+	} else {
+		tt = guess(commit)
+		guessed = true
+	}
+
+	c.addCommit(tt, section, message)
+	breaking := (len(re) > 4 && re[4] != "") || strings.Contains(message, "BREAKING CHANGE")
+	if breaking {
+		c.addBreaking(message)
+	}
+
+	entry := Entry{
+		Hash:     commit.Hash,
+		Type:     tt,
+		Scope:    section,
+		Breaking: breaking,
+		Guessed:  guessed,
+		Summary:  strings.SplitN(message, "\n", 2)[0],
+	}
+	if m := releaseAsFooter.FindStringSubmatch(commit.Message); m != nil {
+		entry.ReleaseAs = m[1]
+	}
+	c.Entries = append(c.Entries, entry)
+}
+
 // Load creates a new CommitSet from a repository
 func Load(r *repo.Repository, stopAt StopAt, guess CommitTypeGuesser) (*ChangeSet, error) {
+	return LoadFiltered(r, stopAt, guess, nil)
+}
+
+// LoadFiltered creates a new CommitSet from a repository, including only those commits accepted by the filter.  A nil
+// filter accepts every commit.
+func LoadFiltered(r *repo.Repository, stopAt StopAt, guess CommitTypeGuesser, filter CommitFilter) (*ChangeSet, error) {
 	defer perf.Timer("Loading changes").Stop()
 
 	changeSet := NewChangeSet()
@@ -57,7 +154,6 @@ func Load(r *repo.Repository, stopAt StopAt, guess CommitTypeGuesser) (*ChangeSe
 
 	defer iter.Close()
 
-	numChanges := 0
 	_ = iter.ForEach(func(commit *object.Commit) error {
 
 		log.Debug().
@@ -72,33 +168,16 @@ func Load(r *repo.Repository, stopAt StopAt, guess CommitTypeGuesser) (*ChangeSe
 			return nil
 		}
 
-		numChanges++
-		message := commit.Message
-		re := commitType.FindStringSubmatch(message)
-		section := ""
-		if len(re) > 3 {
-			section = re[3]
-		}
-
-		var tt TypeTag
-
-		if len(re) > 1 {
-			tt = TypeTag(re[1])
-			message = message[len(re[0]):]
-		} else {
-			tt = guess(commit)
-		}
-
-		changeSet.addCommit(tt, section, message)
-		if (len(re) > 4 && re[4] != "") || strings.Contains(message, "BREAKING CHANGE") {
-			changeSet.addBreaking(message)
+		if filter != nil && !filter(commit) {
+			return nil
 		}
 
+		changeSet.AddCommit(commit, guess)
 		return nil
 	})
 
 	log.Debug().
-		Int("number_of_changes", numChanges).
+		Int("number_of_changes", changeSet.Count).
 		Msg("Number of changes")
 
 	return changeSet, nil
@@ -6,6 +6,7 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/rs/zerolog/log"
+	"strings"
 )
 
 // StopAt is a commit recognizer
@@ -50,10 +51,18 @@ func StopAtTagMatch(r *repo.Repository, matchString func(s string) bool) StopAt
 }
 
 func StopAtFirstSemver(r *repo.Repository) StopAt {
+	return StopAtFirstSemverWithPrefix(r, "")
+}
+
+// StopAtFirstSemverWithPrefix stops at the first commit tagged with the prefix followed by a semver
+func StopAtFirstSemverWithPrefix(r *repo.Repository, prefix string) StopAt {
 	return func(commit *object.Commit) bool {
 		for _, tag := range r.ReverseTagMap()[commit.Hash] {
 			log.Debug().Str("tag", tag).Msg("checking tag")
-			if _, err := semver.NewVersion(tag); err == nil {
+			if !strings.HasPrefix(tag, prefix) {
+				continue
+			}
+			if _, err := semver.NewVersion(tag[len(prefix):]); err == nil {
 				log.Debug().
 					Str("hash", commit.Hash.String()[:6]).
 					Str("tag", tag).
@@ -0,0 +1,65 @@
+package changes
+
+import (
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rs/zerolog/log"
+)
+
+// CommitFilter decides whether a commit should be included in a change set
+type CommitFilter func(commit *object.Commit) bool
+
+// ChangedFiles returns the names of the files changed by the commit, relative to its first parent
+func ChangedFiles(commit *object.Commit) ([]string, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	diffs, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, d := range diffs {
+		if d.From.Name != "" {
+			files = append(files, d.From.Name)
+		}
+		if d.To.Name != "" && d.To.Name != d.From.Name {
+			files = append(files, d.To.Name)
+		}
+	}
+
+	return files, nil
+}
+
+// FilterByFile accepts commits that change at least one file accepted by the given function
+func FilterByFile(accept func(file string) bool) CommitFilter {
+	return func(commit *object.Commit) bool {
+		files, err := ChangedFiles(commit)
+		if err != nil {
+			log.Err(err).
+				Str("hash", commit.Hash.String()[:6]).
+				Msg("Unable to determine changed files")
+			return false
+		}
+
+		for _, f := range files {
+			if accept(f) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,146 @@
+package gomod
+
+import (
+	"fmt"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Module describes a go module found in the repository
+type Module struct {
+	// Path is the module path declared in go.mod
+	Path string
+	// Dir is the slash separated directory of the module, relative to the repository root.  The root module is ""
+	Dir string
+}
+
+// TagPrefix returns the prefix go requires on version tags for this module (e.g. "sub/module/").  A module in a major
+// version subdirectory (e.g. "sub/v2") is tagged without the major version element.
+func (m Module) TagPrefix() string {
+	dir := m.Dir
+	if _, pathMajor, ok := module.SplitPathVersion(m.Path); ok && pathMajor != "" && path.Base(dir) == pathMajor[1:] {
+		dir = path.Dir(dir)
+	}
+
+	if dir == "" || dir == "." {
+		return ""
+	}
+	return dir + "/"
+}
+
+// TagName returns the tag name for the given version of this module
+func (m Module) TagName(version string) string {
+	return m.TagPrefix() + "v" + strings.TrimPrefix(version, "v")
+}
+
+// Contains returns true if the repository relative file is within this module's directory
+func (m Module) Contains(file string) bool {
+	return m.Dir == "" || strings.HasPrefix(file, m.Dir+"/")
+}
+
+// RequiredPath returns the module path required by go for the given major version, and whether it differs from the
+// current module path
+func (m Module) RequiredPath(major int64) (string, bool) {
+	prefix, pathMajor, ok := module.SplitPathVersion(m.Path)
+	if !ok {
+		return m.Path, false
+	}
+
+	if err := module.CheckPathMajor(fmt.Sprintf("v%d.0.0", major), pathMajor); err == nil {
+		return m.Path, false
+	}
+
+	if major < 2 {
+		return prefix, true
+	}
+
+	return fmt.Sprintf("%s/v%d", prefix, major), true
+}
+
+// Modules is a list of modules, ordered by directory
+type Modules []Module
+
+// Owner returns the innermost module which contains the repository relative file
+func (mods Modules) Owner(file string) (Module, bool) {
+	var found Module
+	var ok bool
+	for _, m := range mods {
+		if m.Contains(file) && (!ok || len(m.Dir) > len(found.Dir)) {
+			found = m
+			ok = true
+		}
+	}
+	return found, ok
+}
+
+// Owns returns a function reporting whether a repository relative file belongs to the given module rather than to a
+// module nested within it
+func (mods Modules) Owns(m Module) func(file string) bool {
+	return func(file string) bool {
+		owner, ok := mods.Owner(file)
+		return ok && owner.Dir == m.Dir
+	}
+}
+
+// Discover finds all go modules beneath the given worktree root
+func Discover(root string) (Modules, error) {
+	var mods Modules
+
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if p != root && skipDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.Name() != "go.mod" {
+			return nil
+		}
+
+		// #nosec G304
+		bytes, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		modPath := modfile.ModulePath(bytes)
+		if modPath == "" {
+			return fmt.Errorf("no module path found in %s", p)
+		}
+
+		rel, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+
+		dir := filepath.ToSlash(rel)
+		if dir == "." {
+			dir = ""
+		}
+
+		mods = append(mods, Module{Path: modPath, Dir: dir})
+		return nil
+	})
+
+	sort.Slice(mods, func(i, j int) bool { return mods[i].Dir < mods[j].Dir })
+
+	return mods, err
+}
+
+// skipDir returns true for directories which the go tool ignores
+func skipDir(name string) bool {
+	return name == "vendor" ||
+		name == "testdata" ||
+		strings.HasPrefix(name, ".") ||
+		strings.HasPrefix(name, "_")
+}
@@ -0,0 +1,127 @@
+package gomod
+
+import (
+	"github.com/deweysasser/changetool/test_framework"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModule_TagName(t *testing.T) {
+	tests := []struct {
+		name    string
+		module  Module
+		version string
+		want    string
+	}{
+		{"root", Module{Path: "example.com/m"}, "1.2.3", "v1.2.3"},
+		{"nested", Module{Path: "example.com/m/sub/mod", Dir: "sub/mod"}, "1.4.0", "sub/mod/v1.4.0"},
+		{"major subdirectory", Module{Path: "example.com/m/sub/v2", Dir: "sub/v2"}, "v2.1.0", "sub/v2.1.0"},
+		{"root major subdirectory", Module{Path: "example.com/m/v3", Dir: "v3"}, "3.0.0", "v3.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.module.TagName(tt.version))
+		})
+	}
+}
+
+func TestModule_RequiredPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		major   int64
+		want    string
+		changed bool
+	}{
+		{"v0", "example.com/m", 0, "example.com/m", false},
+		{"v1", "example.com/m", 1, "example.com/m", false},
+		{"to v2", "example.com/m", 2, "example.com/m/v2", true},
+		{"already v2", "example.com/m/v2", 2, "example.com/m/v2", false},
+		{"v2 to v3", "example.com/m/v2", 3, "example.com/m/v3", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := Module{Path: tt.path}.RequiredPath(tt.major)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.changed, changed)
+		})
+	}
+}
+
+func TestModules_Owner(t *testing.T) {
+	mods := Modules{
+		{Path: "example.com/m"},
+		{Path: "example.com/m/sub", Dir: "sub"},
+		{Path: "example.com/m/sub/inner", Dir: "sub/inner"},
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"main.go", ""},
+		{"subway/main.go", ""},
+		{"sub/main.go", "sub"},
+		{"sub/inner/go.mod", "sub/inner"},
+		{"sub/innermost/x.go", "sub"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			owner, ok := mods.Owner(tt.file)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, owner.Dir)
+		})
+	}
+}
+
+func TestDiscoverAndRewrite(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{
+			Message: "feat: initial commit",
+			Contents: map[string]string{
+				"go.mod":                 "module example.com/m\n\ngo 1.17\n",
+				"main.go":                "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/m/sub/pkg\"\n)\n\nfunc main() { fmt.Println(pkg.X) }\n",
+				"sub/go.mod":             "// the sub module\nmodule example.com/m/sub\n\ngo 1.17\n",
+				"sub/pkg/pkg.go":         "package pkg\n\nconst X = 1\n",
+				"testdata/go.mod":        "module ignored\n",
+				"sub/other/other.go":     "package other\n\nimport _ \"example.com/m/subway\"\n",
+				"vendor/example/main.go": "package example\n",
+			},
+		},
+	}))
+
+	mods, err := Discover(r.Path)
+	must(t, err)
+
+	assert.Equal(t, Modules{
+		{Path: "example.com/m"},
+		{Path: "example.com/m/sub", Dir: "sub"},
+	}, mods)
+
+	changed, err := RewriteModulePath(r.Path, mods[1], "example.com/m/sub/v2")
+	must(t, err)
+	assert.Len(t, changed, 2)
+
+	bytes, err := os.ReadFile(filepath.Join(r.Path, "main.go"))
+	must(t, err)
+	assert.Equal(t, "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/m/sub/v2/pkg\"\n)\n\nfunc main() { fmt.Println(pkg.X) }\n", string(bytes))
+
+	bytes, err = os.ReadFile(filepath.Join(r.Path, "sub", "go.mod"))
+	must(t, err)
+	assert.Equal(t, "// the sub module\nmodule example.com/m/sub/v2\n\ngo 1.17\n", string(bytes))
+
+	bytes, err = os.ReadFile(filepath.Join(r.Path, "sub", "other", "other.go"))
+	must(t, err)
+	assert.Contains(t, string(bytes), `"example.com/m/subway"`)
+}
+
+func must(t *testing.T, err error) {
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,129 @@
+package gomod
+
+import (
+	"go/parser"
+	"go/token"
+	"golang.org/x/mod/modfile"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RewriteModulePath changes the module path of the module in go.mod, and every import of the module (or its packages)
+// in go source files beneath the worktree root.  It returns the list of files changed.
+func RewriteModulePath(root string, m Module, newPath string) ([]string, error) {
+	var changed []string
+
+	goMod := filepath.Join(root, filepath.FromSlash(m.Dir), "go.mod")
+	if ok, err := rewriteGoMod(goMod, newPath); err != nil {
+		return changed, err
+	} else if ok {
+		changed = append(changed, goMod)
+	}
+
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if p != root && skipDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(p, ".go") {
+			return nil
+		}
+
+		if ok, err := rewriteImports(p, m.Path, newPath); err != nil {
+			return err
+		} else if ok {
+			changed = append(changed, p)
+		}
+		return nil
+	})
+
+	return changed, err
+}
+
+func rewriteGoMod(filename, newPath string) (bool, error) {
+	// #nosec G304
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+
+	f, err := modfile.Parse(filename, bytes, nil)
+	if err != nil {
+		return false, err
+	}
+
+	if f.Module != nil && f.Module.Mod.Path == newPath {
+		return false, nil
+	}
+
+	if err = f.AddModuleStmt(newPath); err != nil {
+		return false, err
+	}
+
+	out, err := f.Format()
+	if err != nil {
+		return false, err
+	}
+
+	return true, writeKeepingMode(filename, out)
+}
+
+// rewriteImports replaces import paths in place so the formatting of the file is unchanged
+func rewriteImports(filename, oldPath, newPath string) (bool, error) {
+	// #nosec G304
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ImportsOnly)
+	if err != nil {
+		return false, err
+	}
+
+	var out []byte
+	last := 0
+	for _, imp := range f.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			return false, err
+		}
+
+		if importPath != oldPath && !strings.HasPrefix(importPath, oldPath+"/") {
+			continue
+		}
+
+		start := fset.Position(imp.Path.Pos()).Offset
+		end := fset.Position(imp.Path.End()).Offset
+		out = append(out, src[last:start]...)
+		out = append(out, strconv.Quote(newPath+importPath[len(oldPath):])...)
+		last = end
+	}
+
+	if out == nil {
+		return false, nil
+	}
+
+	out = append(out, src[last:]...)
+
+	return true, writeKeepingMode(filename, out)
+}
+
+func writeKeepingMode(filename string, content []byte) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, content, info.Mode())
+}
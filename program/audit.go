@@ -0,0 +1,281 @@
+package program
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Masterminds/semver"
+	"github.com/deweysasser/changetool/changes"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/versions"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rs/zerolog/log"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Audit checks that each release tag has the version the commits since the release before it call for
+type Audit struct {
+	DefaultType            changes.TypeTag `default:"fix" group:"calculation" help:"if type is not specified in commit, assume this type"`
+	GuessMissingCommitType bool            `default:"true" group:"calculation" negatable:"" help:"If commit type is missing, take a guess about which it is"`
+	OutputFormat           string          `group:"output" enum:"text,json" default:"text" help:"how to report the audit (text|json)"`
+}
+
+// Problems an audit can find with a release
+const (
+	ProblemUnderBumped = "under-bumped"
+	ProblemOverBumped  = "over-bumped"
+	ProblemSkipped     = "skipped"
+	ProblemNotAncestor = "not-ancestor"
+)
+
+// AuditedRelease is a release tag checked against the commits since the release before it
+type AuditedRelease struct {
+	Tag     string `json:"tag"`
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	// Date is when the release was tagged, or committed for lightweight tags
+	Date            time.Time `json:"date"`
+	PreviousTag     string    `json:"previous_tag"`
+	PreviousVersion string    `json:"previous_version"`
+	CommitCount     int       `json:"commit_count"`
+	ExpectedBump    string    `json:"expected_bump"`
+	ExpectedVersion string    `json:"expected_version"`
+	ActualBump      string    `json:"actual_bump"`
+	// SetBy describes the "Release-As:" footer which chose the version, if one did
+	SetBy    string   `json:"set_by,omitempty"`
+	Problems []string `json:"problems"`
+	// Contributions are the changes which call for the expected bump
+	Contributions []Contribution `json:"contributions"`
+}
+
+// release is a version tag and the commit it points to
+type release struct {
+	tag     string
+	version semver.Version
+	commit  plumbing.Hash
+	date    time.Time
+}
+
+func (a *Audit) Run(program *Options) error {
+	r, err := program.Repository()
+	if err != nil {
+		return err
+	}
+
+	audited, err := a.audit(r)
+	if err != nil {
+		return err
+	}
+
+	if a.OutputFormat == "json" {
+		enc := json.NewEncoder(program.OutFP)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(audited); err != nil {
+			return err
+		}
+	} else {
+		for _, rel := range audited {
+			writeAuditedRelease(program.OutFP, rel)
+		}
+	}
+
+	failed := 0
+	for _, rel := range audited {
+		if len(rel.Problems) > 0 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d releases have problems", failed, len(audited))
+	}
+
+	return nil
+}
+
+// releases returns the release (not prerelease) version tags ordered by version.  When several tags have the same
+// version, the first by name is used.
+func releases(r *repo.Repository) []release {
+	byVersion := make(map[string]release)
+	for _, tag := range r.AllTags() {
+		v, err := versions.SemVer{}.Parse(tag.Name)
+		if err != nil || v.Prerelease() != "" {
+			continue
+		}
+
+		version := releaseOf(v)
+		key := version.String()
+		if _, found := byVersion[key]; found {
+			continue
+		}
+		byVersion[key] = release{tag: tag.Name, version: version, commit: tag.Commit, date: tag.Date}
+	}
+
+	var list []release
+	for _, rel := range byVersion {
+		list = append(list, rel)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].version.LessThan(&list[j].version)
+	})
+
+	return list
+}
+
+// ancestors returns the commit and all its ancestors
+func ancestors(r *repo.Repository, hash plumbing.Hash) map[plumbing.Hash]bool {
+	seen := make(map[plumbing.Hash]bool)
+
+	stack := []plumbing.Hash{hash}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		commit, err := r.CommitObject(hash)
+		if err != nil {
+			log.Warn().Err(err).Str("commit", hash.String()[:7]).Msg("Unable to read commit")
+			continue
+		}
+		stack = append(stack, commit.ParentHashes...)
+	}
+
+	return seen
+}
+
+// audit checks each release against the one before it
+func (a *Audit) audit(r *repo.Repository) ([]AuditedRelease, error) {
+	c := Changelog{DefaultType: a.DefaultType, GuessMissingCommitType: a.GuessMissingCommitType}
+	guess := c.guesser()
+
+	list := releases(r)
+	audited := make([]AuditedRelease, 0, len(list))
+
+	var previousAncestors map[plumbing.Hash]bool
+	for n, rel := range list {
+		reachable := ancestors(r, rel.commit)
+		if n == 0 {
+			previousAncestors = reachable
+			continue
+		}
+		previous := list[n-1]
+
+		// the changes of the release are the commits reachable from it and not from the previous release
+		var commits []*object.Commit
+		for hash := range reachable {
+			if previousAncestors[hash] {
+				continue
+			}
+			commit, err := r.CommitObject(hash)
+			if err != nil {
+				return nil, err
+			}
+			commits = append(commits, commit)
+		}
+
+		// most recent first, as in the log
+		sort.Slice(commits, func(i, j int) bool {
+			return commits[i].Committer.When.After(commits[j].Committer.When)
+		})
+
+		changeSet := changes.NewChangeSet()
+		for _, commit := range commits {
+			changeSet.AddCommit(commit, guess)
+		}
+
+		audited = append(audited, auditRelease(previous, rel, changeSet, reachable[previous.commit]))
+		previousAncestors = reachable
+	}
+
+	return audited, nil
+}
+
+// auditRelease compares the version of the release with the version the changes since the previous release call for
+func auditRelease(previous, rel release, changeSet *changes.ChangeSet, isAncestor bool) AuditedRelease {
+	expectedBump := bumpFromChangeSet(changeSet, previous.version)
+	expected := expectedBump.Apply(previous.version)
+	actualBump := bumpBetween(previous.version, rel.version)
+
+	a := AuditedRelease{
+		Tag:             rel.tag,
+		Version:         rel.version.String(),
+		Commit:          rel.commit.String()[:7],
+		Date:            rel.date,
+		PreviousTag:     previous.tag,
+		PreviousVersion: previous.version.String(),
+		CommitCount:     changeSet.Count,
+		ExpectedBump:    expectedBump.String(),
+		ExpectedVersion: expected.String(),
+		ActualBump:      actualBump.String(),
+		Problems:        []string{},
+	}
+
+	if !isAncestor {
+		a.Problems = append(a.Problems, ProblemNotAncestor)
+	}
+
+	for _, entry := range changeSet.Entries {
+		if v, err := semver.NewVersion(entry.ReleaseAs); err == nil && v.Equal(&rel.version) {
+			a.SetBy = fmt.Sprintf("Release-As footer in commit %s", entry.Hash.String()[:7])
+			return a
+		}
+	}
+
+	switch {
+	case actualBump < expectedBump:
+		a.Problems = append(a.Problems, ProblemUnderBumped)
+	case actualBump > expectedBump:
+		a.Problems = append(a.Problems, ProblemOverBumped)
+	case !rel.version.Equal(&expected):
+		a.Problems = append(a.Problems, ProblemSkipped)
+	}
+
+	for _, entry := range changeSet.Entries {
+		if bump, rule := entryBump(entry, previous.version); bump == expectedBump && bump != versions.BumpNone {
+			a.Contributions = append(a.Contributions, Contribution{
+				Hash:     entry.Hash.String()[:7],
+				Type:     string(entry.Type),
+				Scope:    entry.Scope,
+				Breaking: entry.Breaking,
+				Guessed:  entry.Guessed,
+				Summary:  entry.Summary,
+				Bump:     bump.String(),
+				Rule:     rule,
+			})
+		}
+	}
+
+	return a
+}
+
+// writeAuditedRelease writes the audit of a release for people to read
+func writeAuditedRelease(out io.Writer, a AuditedRelease) {
+	status := "ok"
+	if len(a.Problems) > 0 {
+		status = strings.Join(a.Problems, ", ")
+	}
+
+	_, _ = fmt.Fprintf(out, "%-10s %s: %s bump from %s (%d commits)", a.Tag, status, a.ActualBump, a.PreviousTag, a.CommitCount)
+
+	switch {
+	case a.SetBy != "":
+		_, _ = fmt.Fprintf(out, ", set by %s\n", a.SetBy)
+	case a.ExpectedVersion != a.Version:
+		_, _ = fmt.Fprintf(out, ", expected a %s bump to %s\n", a.ExpectedBump, a.ExpectedVersion)
+	default:
+		_, _ = fmt.Fprintln(out)
+	}
+
+	if len(a.Problems) == 0 {
+		return
+	}
+
+	for _, c := range a.Contributions {
+		_, _ = fmt.Fprintf(out, "   %s %-5s %s: %s [%s]\n", c.Hash, c.Bump, c.Type, c.Summary, c.Rule)
+	}
+}
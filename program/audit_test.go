@@ -0,0 +1,74 @@
+package program
+
+import (
+	"encoding/json"
+	"github.com/deweysasser/changetool/test_framework"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{Message: "feat: initial commit"},
+		{Tag: "v1.0.0"},
+		{Message: "fix: added a fix"},
+		{Tag: "v1.0.1"},
+		{Message: "feat!: removed the old API"},
+		{Tag: "v1.0.2"},
+		{Message: "fix: added another fix"},
+		{Tag: "v1.1.0"},
+		{Message: "fix: added a third fix"},
+		{Tag: "v1.1.2"},
+		{Message: "chore: jump\n\nRelease-As: 1.3.0\n"},
+		{Tag: "v1.3.0"},
+		{Tag: "v1.3.0-rc.1"},
+	}))
+
+	skipped, err := r.Tag("v1.1.2")
+	must(t, err)
+	_, err = r.CreateTag("v1.3.1", skipped.Hash(), nil)
+	must(t, err)
+
+	t.Run("json", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "audit", "--output-format", "json")
+		assert.EqualError(t, err, "4 of 6 releases have problems")
+
+		var audited []AuditedRelease
+		must(t, json.Unmarshal([]byte(out), &audited))
+
+		problems := make(map[string][]string)
+		for _, a := range audited {
+			problems[a.Tag] = a.Problems
+		}
+		assert.Equal(t, map[string][]string{
+			"v1.0.1": {},
+			"v1.0.2": {ProblemUnderBumped},
+			"v1.1.0": {ProblemOverBumped},
+			"v1.1.2": {ProblemSkipped},
+			"v1.3.0": {},
+			"v1.3.1": {ProblemNotAncestor, ProblemOverBumped},
+		}, problems)
+
+		if assert.Len(t, audited, 6) {
+			under := audited[1]
+			assert.False(t, under.Date.IsZero())
+			assert.Equal(t, "2.0.0", under.ExpectedVersion)
+			if assert.Len(t, under.Contributions, 1) {
+				assert.Equal(t, "removed the old API", under.Contributions[0].Summary)
+				assert.True(t, under.Contributions[0].Breaking)
+			}
+			assert.Contains(t, audited[4].SetBy, "Release-As footer in commit ")
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "audit")
+		assert.Error(t, err)
+		assert.Contains(t, out, "v1.0.1     ok: patch bump from v1.0.0 (1 commits)\n")
+		assert.Contains(t, out, "v1.0.2     under-bumped: patch bump from v1.0.1 (1 commits), expected a major bump to 2.0.0\n")
+		assert.Contains(t, out, " major feat: removed the old API [breaking change]\n")
+	})
+}
@@ -0,0 +1,381 @@
+package program
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/semver"
+	"github.com/deweysasser/changetool/changes"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/versions"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rs/zerolog/log"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Backfill proposes version tags for past releases which were never tagged, calculating each from the commits since
+// the release before it
+type Backfill struct {
+	Anchor                 []string        `group:"source" sep:"none" placeholder:"REV=VERSION" help:"treat the commit as released with VERSION, in addition to the existing version tags"`
+	At                     []string        `group:"source" sep:"none" placeholder:"REV" help:"a commit at which a release was made"`
+	Match                  string          `group:"source" default:"${release_commit_pattern}" placeholder:"REGEX" help:"commits whose subject matches REGEX are release points (default ${release_commit_pattern}).  Empty to use only --at"`
+	DefaultType            changes.TypeTag `default:"fix" group:"calculation" help:"if type is not specified in commit, assume this type"`
+	GuessMissingCommitType bool            `default:"true" group:"calculation" negatable:"" help:"If commit type is missing, take a guess about which it is"`
+	OutputFormat           string          `group:"output" enum:"text,json" default:"text" help:"how to print the plan (text|json)"`
+	Apply                  bool            `help:"create the proposed tags"`
+}
+
+// ReleaseCommitPattern matches the subjects of commits which record a release, such as those made by the release
+// command
+const ReleaseCommitPattern = `^chore\(release\)`
+
+// Status of a release point in a backfill plan
+const (
+	PointTagged    = "tagged"
+	PointAnchor    = "anchor"
+	PointProposed  = "proposed"
+	PointCreated   = "created"
+	PointNoChanges = "no-changes"
+	PointConflict  = "conflict"
+	PointBlocked   = "blocked"
+)
+
+// BackfillPoint is a release point in the history and the version it has or would receive
+type BackfillPoint struct {
+	Commit  string `json:"commit"`
+	Subject string `json:"subject"`
+	Version string `json:"version,omitempty"`
+	Tag     string `json:"tag,omitempty"`
+	Bump    string `json:"bump,omitempty"`
+	Status  string `json:"status"`
+	Note    string `json:"note,omitempty"`
+
+	hash plumbing.Hash
+}
+
+func (b *Backfill) Run(program *Options) error {
+	r, err := program.Repository()
+	if err != nil {
+		return err
+	}
+
+	plan, err := b.plan(r)
+	if err != nil {
+		return err
+	}
+
+	var applyErr error
+	if b.Apply {
+		applyErr = b.apply(r, plan)
+	}
+
+	if b.OutputFormat == "json" {
+		enc := json.NewEncoder(program.OutFP)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(plan); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range plan {
+			writeBackfillPoint(program.OutFP, p)
+		}
+	}
+
+	return applyErr
+}
+
+// writeBackfillPoint writes a line of the plan for people to read
+func writeBackfillPoint(out io.Writer, p BackfillPoint) {
+	version := p.Tag
+	if version == "" {
+		version = "-"
+	}
+
+	line := fmt.Sprintf("%s %-10s %-10s %-5s %s", p.Commit, p.Status, version, p.Bump, p.Subject)
+	if p.Note != "" {
+		line += "  (" + p.Note + ")"
+	}
+	_, _ = fmt.Fprintln(out, strings.TrimRight(line, " "))
+}
+
+// anchors returns the versions of the commits which are known to be released:  version tags and --anchor
+func (b *Backfill) anchors(r *repo.Repository) (map[plumbing.Hash]semver.Version, map[plumbing.Hash]string, error) {
+	versionOf := make(map[plumbing.Hash]semver.Version)
+	tagOf := make(map[plumbing.Hash]string)
+
+	for name, hash := range r.TagMap() {
+		v, err := versions.SemVer{}.Parse(name)
+		if err != nil {
+			continue
+		}
+		if existing, found := versionOf[hash]; !found || v.GreaterThan(&existing) {
+			versionOf[hash] = v
+			tagOf[hash] = name
+		}
+	}
+
+	for _, spec := range b.Anchor {
+		n := strings.LastIndex(spec, "=")
+		if n < 0 {
+			return nil, nil, fmt.Errorf("invalid --anchor %s: expected REV=VERSION", spec)
+		}
+
+		hash, err := r.ResolveRevision(plumbing.Revision(spec[:n]))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --anchor %s: %w", spec, err)
+		}
+
+		v, err := semver.NewVersion(spec[n+1:])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --anchor %s: %w", spec, err)
+		}
+
+		versionOf[*hash] = *v
+		delete(tagOf, *hash)
+	}
+
+	return versionOf, tagOf, nil
+}
+
+// firstParents returns the first parent history of HEAD, oldest first
+func firstParents(r *repo.Repository) ([]*object.Commit, error) {
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	var history []*object.Commit
+	for {
+		history = append(history, commit)
+		if len(commit.ParentHashes) == 0 {
+			break
+		}
+		if commit, err = r.CommitObject(commit.ParentHashes[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+
+	return history, nil
+}
+
+// releasePoints returns the commits given by --at, and those whose subject matches --match
+func (b *Backfill) releasePoints(r *repo.Repository, history []*object.Commit) (map[plumbing.Hash]bool, error) {
+	points := make(map[plumbing.Hash]bool)
+
+	onHistory := make(map[plumbing.Hash]bool)
+	for _, commit := range history {
+		onHistory[commit.Hash] = true
+	}
+
+	for _, rev := range b.At {
+		hash, err := r.ResolveRevision(plumbing.Revision(rev))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --at %s: %w", rev, err)
+		}
+		if !onHistory[*hash] {
+			return nil, fmt.Errorf("--at %s is not on the first parent history of HEAD", rev)
+		}
+		points[*hash] = true
+	}
+
+	if b.Match != "" {
+		match, err := regexp.Compile(b.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --match: %w", err)
+		}
+		for _, commit := range history {
+			if match.MatchString(subject(commit)) {
+				points[commit.Hash] = true
+			}
+		}
+	}
+
+	return points, nil
+}
+
+// subject returns the first line of the commit message
+func subject(commit *object.Commit) string {
+	return strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0]
+}
+
+// plan walks the history of HEAD from the oldest commit, carrying the version from each anchor or release point to
+// the next and bumping it by the changes in between
+func (b *Backfill) plan(r *repo.Repository) ([]BackfillPoint, error) {
+	versionOf, tagOf, err := b.anchors(r)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := firstParents(r)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := b.releasePoints(r, history)
+	if err != nil {
+		return nil, err
+	}
+
+	c := Changelog{DefaultType: b.DefaultType, GuessMissingCommitType: b.GuessMissingCommitType}
+	changeSet, err := changes.Load(r, changes.NeverStop, c.guesser())
+	if err != nil {
+		return nil, err
+	}
+
+	entriesOf := make(map[plumbing.Hash][]changes.Entry)
+	for _, entry := range changeSet.Entries {
+		entriesOf[entry.Hash] = append(entriesOf[entry.Hash], entry)
+	}
+
+	// the versions of later anchors, which proposals must stay below
+	var later []semver.Version
+	for _, commit := range history {
+		if v, found := versionOf[commit.Hash]; found {
+			later = append(later, v)
+		}
+	}
+
+	tags := r.TagMap()
+	seen := make(map[plumbing.Hash]bool)
+	version := semver.Version{}
+	bump := versions.BumpNone
+	// blockedBy is the conflicting point which the points after it are calculated from, until the next known version
+	blockedBy := ""
+
+	var plan []BackfillPoint
+	for _, commit := range history {
+		// the changes released at this commit are the ones it brings in which no earlier commit did
+		for _, hash := range newCommits(r, commit, seen) {
+			for _, entry := range entriesOf[hash] {
+				if changeBump, _ := entryBump(entry, version); changeBump > bump {
+					bump = changeBump
+				}
+			}
+		}
+
+		p := BackfillPoint{Commit: commit.Hash.String()[:7], Subject: subject(commit), hash: commit.Hash}
+
+		if v, found := versionOf[commit.Hash]; found {
+			later = later[1:]
+			p.Version = v.String()
+			p.Tag = tagOf[commit.Hash]
+			p.Status = PointTagged
+			if p.Tag == "" {
+				p.Tag = tagName(p.Version)
+				p.Status = PointAnchor
+			}
+			if v.LessThan(&version) {
+				p.Note = "lower than the version before it"
+			}
+			plan = append(plan, p)
+			version, bump, blockedBy = v, versions.BumpNone, ""
+			continue
+		}
+
+		if !points[commit.Hash] {
+			continue
+		}
+
+		if blockedBy != "" {
+			p.Status = PointBlocked
+			p.Note = "follows the conflict at " + blockedBy
+			plan = append(plan, p)
+			continue
+		}
+
+		if bump == versions.BumpNone {
+			p.Status = PointNoChanges
+			p.Note = "no releasable changes since " + version.String()
+			plan = append(plan, p)
+			continue
+		}
+
+		next := bump.Apply(version)
+		p.Version = next.String()
+		p.Tag = tagName(p.Version)
+		p.Bump = bump.String()
+		p.Status = PointProposed
+
+		target, exists := tags[p.Tag]
+		switch {
+		case exists:
+			p.Status = PointConflict
+			p.Note = fmt.Sprintf("tag %s already exists on %s", p.Tag, target.String()[:7])
+		case len(later) > 0 && !next.LessThan(&later[0]):
+			p.Status = PointConflict
+			p.Note = fmt.Sprintf("not lower than the later release %s", later[0].String())
+		}
+		if p.Status == PointConflict {
+			blockedBy = p.Commit
+		}
+		plan = append(plan, p)
+
+		version, bump = next, versions.BumpNone
+	}
+
+	return plan, nil
+}
+
+// newCommits returns the commit and those of its ancestors which are not yet seen, marking them seen.  Walking the
+// first parent history oldest first, these are the commits the commit brings in, including those of merged branches.
+func newCommits(r *repo.Repository, commit *object.Commit, seen map[plumbing.Hash]bool) []plumbing.Hash {
+	var found []plumbing.Hash
+
+	stack := []plumbing.Hash{commit.Hash}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		found = append(found, hash)
+
+		c, err := r.CommitObject(hash)
+		if err != nil {
+			log.Warn().Err(err).Str("commit", hash.String()[:7]).Msg("Unable to read commit")
+			continue
+		}
+		stack = append(stack, c.ParentHashes...)
+	}
+
+	return found
+}
+
+// apply creates the tags proposed by the plan, leaving the other points alone.  It creates none if any point
+// conflicts, since the versions after a conflict depend on how it is resolved.
+func (b *Backfill) apply(r *repo.Repository, plan []BackfillPoint) error {
+	for _, p := range plan {
+		if p.Status == PointConflict {
+			return errors.New("some release points conflict with existing versions, so no tags were created")
+		}
+	}
+
+	for i, p := range plan {
+		if p.Status != PointProposed {
+			continue
+		}
+
+		log.Info().Str("tag", p.Tag).Str("commit", p.Commit).Msg("Creating tag")
+		_, err := r.CreateTag(p.Tag, p.hash, &git.CreateTagOptions{Message: fmt.Sprintf("Tag version %s", p.Version)})
+		if err != nil {
+			return fmt.Errorf("unable to create tag %s on %s: %w", p.Tag, p.Commit, err)
+		}
+		plan[i].Status = PointCreated
+	}
+
+	return nil
+}
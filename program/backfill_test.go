@@ -0,0 +1,94 @@
+package program
+
+import (
+	"encoding/json"
+	"github.com/deweysasser/changetool/test_framework"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBackfill(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{Message: "feat: initial commit"},
+		{Tag: "v1.0.0"},
+		{Message: "fix: added a fix"},
+		{Message: "chore(release): first fix release"},
+		{Message: "feat: added a feat"},
+		{Message: "docs: documented the feat"},
+		{Message: "chore(release): nothing to release"},
+		{Message: "chore: unreleased"},
+		{Message: "feat!: removed the old API"},
+		{Message: "chore: the last commit"},
+	}))
+
+	plan := func(t *testing.T, args ...string) []BackfillPoint {
+		out, err := runCommand(t, r.Path, append([]string{"backfill", "--output-format", "json"}, args...)...)
+		assert.NoError(t, err)
+
+		var points []BackfillPoint
+		must(t, json.Unmarshal([]byte(out), &points))
+		for i := range points {
+			points[i].Commit = ""
+			points[i].Note = ""
+		}
+		return points
+	}
+
+	t.Run("plan", func(t *testing.T) {
+		assert.Equal(t, []BackfillPoint{
+			{Subject: "feat: initial commit", Version: "1.0.0", Tag: "v1.0.0", Status: PointTagged},
+			{Subject: "chore(release): first fix release", Version: "1.0.1", Tag: "v1.0.1", Bump: "patch", Status: PointProposed},
+			{Subject: "chore(release): nothing to release", Version: "1.1.0", Tag: "v1.1.0", Bump: "minor", Status: PointProposed},
+			{Subject: "chore: the last commit", Version: "2.0.0", Tag: "v2.0.0", Bump: "major", Status: PointProposed},
+		}, plan(t, "--at", "HEAD"))
+
+		_, err := r.Tag("v1.0.1")
+		assert.Error(t, err, "the plan creates no tags")
+	})
+
+	t.Run("anchor", func(t *testing.T) {
+		assert.Equal(t, []BackfillPoint{
+			{Subject: "feat: initial commit", Version: "1.0.0", Tag: "v1.0.0", Status: PointTagged},
+			{Subject: "chore(release): first fix release", Version: "1.0.1", Tag: "v1.0.1", Bump: "patch", Status: PointConflict},
+			{Subject: "docs: documented the feat", Version: "1.0.1", Tag: "v1.0.1", Status: PointAnchor},
+			{Subject: "chore(release): nothing to release", Status: PointNoChanges},
+		}, plan(t, "--anchor", "HEAD~4=1.0.1"))
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		assert.Equal(t, []BackfillPoint{
+			{Subject: "feat: initial commit", Version: "1.0.0", Tag: "v1.0.0", Status: PointTagged},
+			{Subject: "chore(release): first fix release", Version: "1.0.1", Tag: "v1.0.1", Bump: "patch", Status: PointConflict},
+			{Subject: "chore(release): nothing to release", Status: PointBlocked},
+			{Subject: "chore: the last commit", Version: "1.0.1", Tag: "v1.0.1", Status: PointAnchor},
+		}, plan(t, "--anchor", "HEAD=1.0.1"))
+	})
+
+	t.Run("apply with a conflict", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "backfill", "--anchor", "HEAD~4=1.0.1", "--at", "HEAD", "--apply")
+		assert.Error(t, err)
+
+		_, err = r.Tag("v2.0.0")
+		assert.Error(t, err, "nothing is tagged when a point conflicts")
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "backfill", "--apply")
+		assert.NoError(t, err)
+		assert.Contains(t, out, " created    v1.1.0     minor chore(release): nothing to release\n")
+
+		for _, name := range []string{"v1.0.1", "v1.1.0"} {
+			_, err = r.Tag(name)
+			assert.NoError(t, err, name)
+		}
+
+		assert.Equal(t, []BackfillPoint{
+			{Subject: "feat: initial commit", Version: "1.0.0", Tag: "v1.0.0", Status: PointTagged},
+			{Subject: "chore(release): first fix release", Version: "1.0.1", Tag: "v1.0.1", Status: PointTagged},
+			{Subject: "chore(release): nothing to release", Version: "1.1.0", Tag: "v1.1.0", Status: PointTagged},
+		}, plan(t))
+	})
+}
@@ -0,0 +1,58 @@
+package program
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/deweysasser/changetool/versions"
+	"io"
+)
+
+// Candidate is the version which a bump level would give
+type Candidate struct {
+	Bump    string `json:"bump"`
+	Version string `json:"version"`
+	// Calculated is true for the bump level the changes call for
+	Calculated bool `json:"calculated"`
+}
+
+// candidates returns the next version for each bump level, most significant first.  Levels for which the scheme has
+// no next version are left out.
+func candidates(calc *Calculation) []Candidate {
+	base := releaseOf(calc.PreviousVersion)
+
+	var list []Candidate
+	for _, bump := range []versions.Bump{versions.BumpMajor, versions.BumpMinor, versions.BumpPatch, versions.BumpNone} {
+		next, err := calc.Scheme.Next(base, bump, calc.date)
+		if err != nil {
+			continue
+		}
+		list = append(list, Candidate{
+			Bump:       bump.String(),
+			Version:    calc.format(next),
+			Calculated: bump == calc.Calculated,
+		})
+	}
+
+	return list
+}
+
+// writeCandidates reports the candidate versions as text or json
+func (s *Semver) writeCandidates(out io.Writer, calc *Calculation) error {
+	list := candidates(calc)
+
+	if s.OutputFormat == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+
+	for _, c := range list {
+		marker := ""
+		if c.Calculated {
+			marker = " (calculated)"
+		}
+		_, _ = fmt.Fprintf(out, "%-5s %s%s\n", c.Bump, c.Version, marker)
+	}
+
+	return nil
+}
@@ -2,11 +2,14 @@ package program
 
 import (
 	"fmt"
+	"github.com/deweysasser/changetool/apicheck"
 	"github.com/deweysasser/changetool/changes"
 	"github.com/deweysasser/changetool/perf"
 	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/versions"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/rs/zerolog/log"
+	"io"
 	"strings"
 )
 
@@ -17,6 +20,7 @@ type Changelog struct {
 	DefaultType            changes.TypeTag   `default:"fix" group:"calculation" help:"if type is not specified in commit, assume this type"`
 	GuessMissingCommitType bool              `default:"true" group:"calculation" negatable:"" help:"If commit type is missing, take a guess about which it is"`
 	Order                  []changes.TypeTag `default:"${type_order}" group:"calculation" help:"order in which to list commit message types"`
+	GoAPICheck             bool              `group:"calculation" help:"detect breaking changes by comparing the exported go API with the previous version tag"`
 }
 
 func (c *Changelog) Run(program *Options) error {
@@ -31,41 +35,135 @@ func (c *Changelog) Run(program *Options) error {
 		return err
 	}
 
-	for _, section := range changes.CommitEntries(c.Order, changeSet.Commits) {
-		_, _ = fmt.Fprintf(program.OutFP, "%s:\n", section.Name)
+	c.write(program.OutFP, changeSet)
+
+	return nil
+}
 
-		for _, commit := range section.Messages {
-			message := commit
-			if message[len(message)-1] == '\n' {
-				message = message[:len(message)-1]
+// write writes the changelog of the change set.  With --go-api-check, the incompatible API changes come first.
+func (c *Changelog) write(out io.Writer, changeSet *changes.ChangeSet) {
+	if c.GoAPICheck {
+		var breaking []string
+		for _, entry := range changeSet.Entries {
+			if entry.Type == apiChangeType && entry.Breaking {
+				breaking = append(breaking, entry.Summary)
 			}
+		}
+		if len(breaking) > 0 {
+			writeSection(out, "Breaking Changes", breaking)
+		}
+	}
 
-			_, _ = fmt.Fprintf(program.OutFP, "   * %s", strings.ReplaceAll(message, "\n", "\n     "))
-			_, _ = fmt.Fprintln(program.OutFP)
+	for _, section := range changes.CommitEntries(c.Order, changeSet.Commits) {
+		writeSection(out, section.Name, section.Messages)
+	}
+}
 
+// notes returns the changelog of the change set as text
+func (c *Changelog) notes(changeSet *changes.ChangeSet) string {
+	var b strings.Builder
+	c.write(&b, changeSet)
+	return b.String()
+}
+
+// writeSection writes a titled list of messages
+func writeSection(out io.Writer, name string, messages []string) {
+	_, _ = fmt.Fprintf(out, "%s:\n", name)
+
+	for _, commit := range messages {
+		message := commit
+		if message[len(message)-1] == '\n' {
+			message = message[:len(message)-1]
 		}
-		_, _ = fmt.Fprintln(program.OutFP)
-	}
 
-	return nil
+		_, _ = fmt.Fprintf(out, "   * %s", strings.ReplaceAll(message, "\n", "\n     "))
+		_, _ = fmt.Fprintln(out)
+
+	}
+	_, _ = fmt.Fprintln(out)
 }
 
 func (c *Changelog) CalculateChanges(r *repo.Repository) (*changes.ChangeSet, error) {
 	defer perf.Timer("Calculating Changes").Stop()
 
-	var guess changes.CommitTypeGuesser
-	if c.GuessMissingCommitType {
-		guess = c.guessType
-	} else {
-		guess = func(commit *object.Commit) changes.TypeTag {
-			return c.DefaultType
-		}
+	stopAt, err := c.findStopCriteria(r)
+	if err != nil {
+		return nil, err
 	}
 
-	if stopAt, err := c.findStopCriteria(r); err != nil {
+	changeSet, err := changes.Load(r, stopAt, c.guesser())
+	if err != nil {
 		return nil, err
-	} else {
-		return changes.Load(r, stopAt, guess)
+	}
+
+	if c.GoAPICheck {
+		if err = c.addAPIChanges(r, changeSet); err != nil {
+			return nil, err
+		}
+	}
+
+	return changeSet, nil
+}
+
+// apiChangeType is the type given to changes found by comparing go APIs
+const apiChangeType changes.TypeTag = "api"
+
+// addAPIChanges compares the go API at the base version tag with HEAD, recording incompatible changes as breaking
+// and compatible changes as additions
+func (c *Changelog) addAPIChanges(r *repo.Repository, changeSet *changes.ChangeSet) error {
+	baseTag := c.SinceTag
+	if baseTag == "" {
+		_, tag, err := versions.FindPreviousVersionFromTag(r)
+		if err != nil {
+			return err
+		}
+		baseTag = tag
+	}
+
+	if baseTag == "" {
+		log.Debug().Msg("No previous version tag, skipping API check")
+		return nil
+	}
+
+	base, found := r.TagMap()[baseTag]
+	if !found {
+		return fmt.Errorf("unable to find tag %s", baseTag)
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return err
+	}
+
+	report, err := apicheck.Check(r, base, head.Hash())
+	if err != nil {
+		return err
+	}
+
+	log.Debug().
+		Str("base", baseTag).
+		Int("incompatible", len(report.Incompatible)).
+		Int("compatible", len(report.Compatible)).
+		Msg("Checked go API")
+
+	for _, change := range report.Incompatible {
+		changeSet.AddFinding(apiChangeType, change, true)
+	}
+
+	for _, change := range report.Compatible {
+		changeSet.AddFinding(apiChangeType, change, false)
+	}
+
+	return nil
+}
+
+// guesser returns the function used to assign a type to commits which do not declare one
+func (c *Changelog) guesser() changes.CommitTypeGuesser {
+	if c.GuessMissingCommitType {
+		return c.guessType
+	}
+	return func(commit *object.Commit) changes.TypeTag {
+		return c.DefaultType
 	}
 }
 
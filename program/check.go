@@ -0,0 +1,51 @@
+package program
+
+import (
+	"fmt"
+	"github.com/Masterminds/semver"
+	"github.com/deweysasser/changetool/versions"
+)
+
+// checkVersion verifies that the supplied version is the one the changes call for.  Prerelease and metadata are
+// ignored, so that e.g. a release candidate of the right version is accepted.
+func checkVersion(calc *Calculation, supplied semver.Version) error {
+	expected := releaseOf(calc.NextVersion)
+	got := releaseOf(supplied)
+
+	since := "the beginning of history"
+	if calc.PreviousTag != "" {
+		since = calc.PreviousTag
+	}
+
+	switch got.Compare(&expected) {
+	case 0:
+		return nil
+	case -1:
+		return fmt.Errorf("version %s under-bumps: it is a %s bump from %s, but the changes since %s require a %s bump to %s",
+			calc.format(supplied), bumpBetween(calc.PreviousVersion, got), calc.previous(), since, calc.Bump, calc.format(expected))
+	default:
+		return fmt.Errorf("version %s over-bumps: it is a %s bump from %s, but the changes since %s only call for a %s bump to %s",
+			calc.format(supplied), bumpBetween(calc.PreviousVersion, got), calc.previous(), since, calc.Bump, calc.format(expected))
+	}
+}
+
+// releaseOf returns the version without prerelease or metadata
+func releaseOf(v semver.Version) semver.Version {
+	v, _ = v.SetPrerelease("")
+	v, _ = v.SetMetadata("")
+	return v
+}
+
+// bumpBetween returns the level of the most significant difference between two versions
+func bumpBetween(from, to semver.Version) versions.Bump {
+	switch {
+	case from.Major() != to.Major():
+		return versions.BumpMajor
+	case from.Minor() != to.Minor():
+		return versions.BumpMinor
+	case from.Patch() != to.Patch():
+		return versions.BumpPatch
+	default:
+		return versions.BumpNone
+	}
+}
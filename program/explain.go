@@ -0,0 +1,202 @@
+package program
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Masterminds/semver"
+	"github.com/deweysasser/changetool/changes"
+	"github.com/deweysasser/changetool/versions"
+	"io"
+	"strings"
+)
+
+// Explanation describes how the next version was decided
+type Explanation struct {
+	PreviousVersion string         `json:"previous_version"`
+	PreviousSource  string         `json:"previous_source"`
+	Range           string         `json:"range"`
+	CommitCount     int            `json:"commit_count"`
+	Contributions   []Contribution `json:"contributions"`
+	Clean           bool           `json:"clean"`
+	DirtyFiles      []string       `json:"dirty_files"`
+	DirtyBump       string         `json:"dirty_bump,omitempty"`
+	Bump            string         `json:"bump"`
+	BumpSetBy       string         `json:"bump_set_by,omitempty"`
+	CalculatedBump  string         `json:"calculated_bump"`
+	SetBy           string         `json:"set_by,omitempty"`
+	Line            string         `json:"maintenance_line,omitempty"`
+	Branch          string         `json:"branch,omitempty"`
+	CappedFrom      string         `json:"capped_from,omitempty"`
+	Violation       string         `json:"guard_violation,omitempty"`
+	NextVersion     string         `json:"next_version"`
+}
+
+// Contribution is a change which contributed to the version bump
+type Contribution struct {
+	Hash     string `json:"hash,omitempty"`
+	Type     string `json:"type"`
+	Scope    string `json:"scope,omitempty"`
+	Breaking bool   `json:"breaking"`
+	Guessed  bool   `json:"guessed"`
+	Summary  string `json:"summary"`
+	Bump     string `json:"bump"`
+	Rule     string `json:"rule"`
+}
+
+// explain builds the explanation of the calculation
+func (s *Semver) explain(calc *Calculation) Explanation {
+	e := Explanation{
+		PreviousVersion: calc.previous(),
+		CommitCount:     calc.Changes.Count,
+		Clean:           len(calc.Dirty) == 0,
+		DirtyFiles:      calc.Dirty,
+		Bump:            calc.Bump.String(),
+		BumpSetBy:       calc.BumpSetBy,
+		CalculatedBump:  calc.Calculated.String(),
+		SetBy:           calc.SetBy,
+		Violation:       calc.Violation,
+		NextVersion:     calc.next(),
+	}
+
+	if calc.Line != nil {
+		e.Line = calc.Line.String()
+		e.Branch = calc.Branch
+	}
+	if len(calc.Dirty) > 0 {
+		e.DirtyBump = calc.DirtyBump.String()
+	}
+	if calc.CappedFrom != versions.BumpNone {
+		e.CappedFrom = calc.CappedFrom.String()
+	}
+
+	switch {
+	case s.FromFile != "" && calc.PreviousTag == "":
+		e.PreviousSource = "file " + s.FromFile
+	case calc.PreviousTag != "":
+		e.PreviousSource = "tag " + calc.PreviousTag
+	default:
+		e.PreviousSource = "none found, starting from 0.0.0"
+	}
+
+	head := calc.Head.String()[:7]
+	switch {
+	case s.SinceTag != "":
+		e.Range = s.SinceTag + ".." + head
+	case s.AllCommits:
+		e.Range = fmt.Sprintf("last %d commits to %s", s.MaxCommits, head)
+	default:
+		e.Range = "most recent version tag.." + head
+	}
+
+	base, _ := calc.PreviousVersion.SetPrerelease("")
+	for _, entry := range calc.Changes.Entries {
+		bump, rule := entryBump(entry, base)
+		if bump == versions.BumpNone {
+			continue
+		}
+
+		c := Contribution{
+			Type:     string(entry.Type),
+			Scope:    entry.Scope,
+			Breaking: entry.Breaking,
+			Guessed:  entry.Guessed,
+			Summary:  entry.Summary,
+			Bump:     bump.String(),
+			Rule:     rule,
+		}
+		if !entry.Hash.IsZero() {
+			c.Hash = entry.Hash.String()[:7]
+		}
+		e.Contributions = append(e.Contributions, c)
+	}
+
+	return e
+}
+
+// entryBump returns the bump a single change calls for, and the rule which decided it
+func entryBump(entry changes.Entry, version semver.Version) (versions.Bump, string) {
+	switch {
+	case entry.Breaking && entry.Type == apiChangeType && version.Major() == 0:
+		return versions.BumpMinor, "incompatible go API change before 1.0.0"
+	case entry.Breaking && entry.Type == apiChangeType:
+		return versions.BumpMajor, "incompatible go API change"
+	case entry.Breaking && version.Major() == 0:
+		return versions.BumpMinor, "breaking change before 1.0.0"
+	case entry.Breaking:
+		return versions.BumpMajor, "breaking change"
+	case entry.Type == apiChangeType:
+		return versions.BumpMinor, "compatible go API addition"
+	case entry.Type == "feat":
+		return versions.BumpMinor, "feat commit"
+	case entry.Type == "fix":
+		return versions.BumpPatch, "fix commit"
+	default:
+		return versions.BumpNone, ""
+	}
+}
+
+// WriteJSON writes the explanation as a JSON object
+func (e Explanation) WriteJSON(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+// WriteText writes the explanation for people to read
+func (e Explanation) WriteText(out io.Writer) error {
+	var b strings.Builder
+
+	_, _ = fmt.Fprintf(&b, "Previous version: %s (from %s)\n", e.PreviousVersion, e.PreviousSource)
+	_, _ = fmt.Fprintf(&b, "Commit range:     %s (%d commits)\n", e.Range, e.CommitCount)
+
+	if len(e.Contributions) == 0 {
+		b.WriteString("Contributing changes: none\n")
+	} else {
+		b.WriteString("Contributing changes:\n")
+		for _, c := range e.Contributions {
+			hash := c.Hash
+			if hash == "" {
+				hash = "-------"
+			}
+			typ := c.Type
+			if c.Guessed {
+				typ += " (guessed)"
+			}
+			_, _ = fmt.Fprintf(&b, "   %s %-5s %s: %s [%s]\n", hash, c.Bump, typ, c.Summary, c.Rule)
+		}
+	}
+
+	switch {
+	case e.Clean:
+		b.WriteString("Worktree:         clean\n")
+	case e.DirtyBump == versions.BumpNone.String():
+		_, _ = fmt.Fprintf(&b, "Worktree:         dirty, making a dirty prerelease (%s)\n", strings.Join(e.DirtyFiles, ", "))
+	default:
+		_, _ = fmt.Fprintf(&b, "Worktree:         dirty, making a %s bump and dirty prerelease (%s)\n", e.DirtyBump, strings.Join(e.DirtyFiles, ", "))
+	}
+
+	if e.BumpSetBy != "" {
+		_, _ = fmt.Fprintf(&b, "Bump set:         %s by %s, instead of the %s the changes call for\n", e.Bump, e.BumpSetBy, e.CalculatedBump)
+	}
+
+	if e.Line != "" {
+		_, _ = fmt.Fprintf(&b, "Maintenance line: %s (branch %s)\n", e.Line, e.Branch)
+	}
+
+	if e.CappedFrom != "" {
+		_, _ = fmt.Fprintf(&b, "Bump capped:      %s to %s by maintenance line %s\n", e.CappedFrom, e.Bump, e.Line)
+	}
+
+	if e.SetBy != "" {
+		_, _ = fmt.Fprintf(&b, "Version set:      %s by %s, instead of the calculated version\n", e.NextVersion, e.SetBy)
+	}
+
+	_, _ = fmt.Fprintf(&b, "Decision:         %s bump, %s -> %s\n", e.Bump, e.PreviousVersion, e.NextVersion)
+
+	if e.Violation != "" {
+		_, _ = fmt.Fprintf(&b, "Guard:            refused, because %s\n", e.Violation)
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
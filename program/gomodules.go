@@ -0,0 +1,155 @@
+package program
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Masterminds/semver"
+	"github.com/deweysasser/changetool/changes"
+	"github.com/deweysasser/changetool/gomod"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/versions"
+	"github.com/rs/zerolog/log"
+)
+
+// GoModules calculates the next version of each go module in the repository
+type GoModules struct {
+	DefaultType            changes.TypeTag `default:"fix" group:"calculation" help:"if type is not specified in commit, assume this type"`
+	GuessMissingCommitType bool            `default:"true" group:"calculation" negatable:"" help:"If commit type is missing, take a guess about which it is"`
+	AllowUntracked         bool            `group:"calculation" help:"allow untracked files to count as clean"`
+	RewriteMajor           bool            `group:"locations" help:"rewrite the module path in go.mod and imports when a module moves to a new major version"`
+}
+
+// ModuleVersion is the calculated version of a single go module
+type ModuleVersion struct {
+	Module          gomod.Module
+	PreviousVersion semver.Version
+	PreviousTag     string
+	NextVersion     semver.Version
+	// RequiredPath is the module path go requires for NextVersion, if different from the current module path
+	RequiredPath string
+}
+
+// NextTag is the tag name for the next version of the module
+func (mv ModuleVersion) NextTag() string {
+	return mv.Module.TagName(mv.NextVersion.String())
+}
+
+func (g *GoModules) Run(program *Options) error {
+	r, err := program.Repository()
+	if err != nil {
+		return err
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+
+	root := w.Filesystem.Root()
+
+	mods, err := gomod.Discover(root)
+	if err != nil {
+		return err
+	}
+
+	if len(mods) == 0 {
+		return errors.New("no go modules found in repository")
+	}
+
+	results, err := g.calculate(r, mods)
+	if err != nil {
+		return err
+	}
+
+	for _, mv := range results {
+		dir := mv.Module.Dir
+		if dir == "" {
+			dir = "."
+		}
+
+		if mv.RequiredPath == "" {
+			_, _ = fmt.Fprintf(program.OutFP, "%s\t%s\t%s\n", dir, mv.Module.Path, mv.NextTag())
+			continue
+		}
+
+		log.Warn().
+			Str("module", mv.Module.Path).
+			Str("required_path", mv.RequiredPath).
+			Str("version", mv.NextVersion.String()).
+			Msg("Module path must change for new major version")
+
+		_, _ = fmt.Fprintf(program.OutFP, "%s\t%s\t%s\t%s\n", dir, mv.Module.Path, mv.NextTag(), mv.RequiredPath)
+
+		if g.RewriteMajor {
+			changed, err := gomod.RewriteModulePath(root, mv.Module, mv.RequiredPath)
+			if err != nil {
+				return err
+			}
+			log.Info().
+				Str("module", mv.RequiredPath).
+				Int("files", len(changed)).
+				Msg("Rewrote module path")
+		}
+	}
+
+	return nil
+}
+
+// calculate finds the next version of each of the given modules
+func (g *GoModules) calculate(r *repo.Repository, mods gomod.Modules) ([]ModuleVersion, error) {
+	c := Changelog{DefaultType: g.DefaultType, GuessMissingCommitType: g.GuessMissingCommitType}
+
+	status, head, err := gitWorktreeStatus(r)
+	if err != nil {
+		return nil, err
+	}
+	dirty := dirtyFiles(status, g.AllowUntracked)
+
+	var results []ModuleVersion
+
+	for _, m := range mods {
+		owns := mods.Owns(m)
+		prefix := m.TagPrefix()
+
+		previous, tag, err := versions.FindPreviousVersionFromTagPrefix(r, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		changeSet, err := changes.LoadFiltered(r,
+			changes.StopAtFirstSemverWithPrefix(r, prefix),
+			c.guesser(),
+			changes.FilterByFile(owns))
+		if err != nil {
+			return nil, err
+		}
+
+		next := previous
+		next, _ = next.SetPrerelease("")
+		next, _ = next.SetMetadata("")
+		next = nextVersionFromChangeSet(changeSet, next)
+
+		for _, f := range dirty {
+			if owns(f) {
+				next = next.IncMinor()
+				next, _ = next.SetPrerelease(fmt.Sprintf("dirty.%s", head.Hash().String()[:6]))
+				break
+			}
+		}
+
+		mv := ModuleVersion{Module: m, PreviousVersion: previous, PreviousTag: tag, NextVersion: next}
+		if required, changed := m.RequiredPath(next.Major()); changed {
+			mv.RequiredPath = required
+		}
+
+		log.Debug().
+			Str("module", m.Path).
+			Str("previous_tag", tag).
+			Str("next_tag", mv.NextTag()).
+			Msg("Calculated module version")
+
+		results = append(results, mv)
+	}
+
+	return results, nil
+}
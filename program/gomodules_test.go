@@ -0,0 +1,84 @@
+package program
+
+import (
+	"github.com/deweysasser/changetool/test_framework"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestGoModules(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{
+			Message: "feat: initial commit",
+			Contents: map[string]string{
+				"go.mod":         "module example.com/m\n\ngo 1.17\n",
+				"main.go":        "package main\n\nimport \"example.com/m/sub/pkg\"\n\nvar _ = pkg.X\n",
+				"sub/go.mod":     "module example.com/m/sub\n\ngo 1.17\n",
+				"sub/pkg/pkg.go": "package pkg\n\nconst X = 1\n",
+			},
+		},
+		{Tag: "v1.0.0"},
+		{Tag: "sub/v1.3.0"},
+		{Message: "fix: root only", Files: []string{"main.go"}},
+	}))
+
+	t.Run("Root fix", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "go-modules")
+		assert.NoError(t, err)
+		assert.Equal(t, ".\texample.com/m\tv1.0.1\nsub\texample.com/m/sub\tsub/v1.3.0\n", out)
+	})
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{Message: "feat!: break the sub module", Files: []string{"sub/pkg/pkg.go"}},
+	}))
+
+	t.Run("Sub module major", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "go-modules")
+		assert.NoError(t, err)
+		assert.Equal(t, ".\texample.com/m\tv1.0.1\nsub\texample.com/m/sub\tsub/v2.0.0\texample.com/m/sub/v2\n", out)
+	})
+
+	t.Run("Rewrite", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "go-modules", "--rewrite-major")
+		assert.NoError(t, err)
+
+		bytes, err := os.ReadFile(path.Join(r.Path, "main.go"))
+		must(t, err)
+		assert.Contains(t, string(bytes), `"example.com/m/sub/v2/pkg"`)
+	})
+}
+
+// runCommand runs the program with the given arguments against the repo, returning the output
+func runCommand(t *testing.T, repo string, args ...string) (string, error) {
+	opts := Options{}
+	dir := test_framework.TestDir(t)
+	output := path.Join(dir, "output.txt")
+
+	args = append([]string{args[0], "--path", repo, "--output", output}, args[1:]...)
+
+	log.Debug().Strs("args", args).Msg("Parsing")
+
+	context, err := opts.Parse(args)
+	if err != nil {
+		return "", err
+	}
+
+	err = context.Run(&opts)
+
+	if opts.OutFP != nil {
+		_ = opts.OutFP.Close()
+	}
+
+	bytes, readErr := os.ReadFile(output)
+	if readErr != nil && err == nil {
+		err = readErr
+	}
+
+	return string(bytes), err
+}
@@ -0,0 +1,106 @@
+package program
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Masterminds/semver"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/versions"
+	"strings"
+)
+
+// Guard refuses versions beyond a cap, so that an errant breaking change marker doesn't silently make a major release
+type Guard struct {
+	MaxVersion string `group:"guard" placeholder:"LINE" help:"fail if the next version is beyond LINE, e.g. 1.x or 1.4.x.  Defaults to the changetool.maxVersion git config of the repository"`
+	MaxBump    string `group:"guard" enum:",major,minor,patch" default:"" help:"fail if the changes call for a bigger bump than this (major|minor|patch).  Defaults to the changetool.maxBump git config of the repository"`
+	AllowMajor bool   `group:"guard" help:"allow a major release beyond --max-version or --max-bump"`
+}
+
+// limits returns the version cap and the largest bump allowed, from the flags or the repository's git config.  The
+// line is nil and the bump is major when there is no limit.
+func (g *Guard) limits(r *repo.Repository) (*versions.Line, versions.Bump, error) {
+	maxVersion, maxBump := g.MaxVersion, g.MaxBump
+
+	cfg, err := r.Config()
+	if err != nil {
+		return nil, versions.BumpMajor, err
+	}
+
+	section := cfg.Raw.Section("changetool")
+	if maxVersion == "" {
+		maxVersion = section.Option("maxVersion")
+	}
+	if maxBump == "" {
+		maxBump = section.Option("maxBump")
+	}
+
+	var line *versions.Line
+	if maxVersion != "" {
+		l, err := versions.ParseLine(maxVersion)
+		if err != nil {
+			return nil, versions.BumpMajor, fmt.Errorf("invalid maximum version: %w", err)
+		}
+		line = &l
+	}
+
+	bump := versions.BumpMajor
+	if maxBump != "" {
+		if bump, err = versions.ParseBump(maxBump); err != nil {
+			return nil, versions.BumpMajor, fmt.Errorf("invalid maximum bump: %w", err)
+		}
+	}
+
+	return line, bump, nil
+}
+
+// checkLimits records in the calculation why the next version is beyond the limits, if it is.  --allow-major lifts
+// the limits for a major release.
+func (g *Guard) checkLimits(r *repo.Repository, calc *Calculation, next semver.Version) error {
+	line, maxBump, err := g.limits(r)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case g.AllowMajor && calc.Bump == versions.BumpMajor:
+	case line != nil && line.Exceeds(next):
+		calc.Violation = fmt.Sprintf("version %s is beyond the maximum version %s", calc.format(next), line)
+	case calc.Bump > maxBump:
+		calc.Violation = fmt.Sprintf("%s is bigger than the maximum %s bump", calc.describeBump(), maxBump)
+	}
+
+	return nil
+}
+
+// guard fails if the next version is beyond the limits, listing the changes which call for it
+func (c *Calculation) guard() error {
+	if c.Violation == "" {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString(c.Violation)
+
+	switch {
+	case c.SetBy != "":
+		_, _ = fmt.Fprintf(&b, " (set by %s)", c.SetBy)
+	case c.BumpSetBy == "":
+		b.WriteString(", because of these changes:")
+		base := releaseOf(c.PreviousVersion)
+		for _, entry := range c.Changes.Entries {
+			if bump, rule := entryBump(entry, base); bump == c.Bump {
+				hash := "-------"
+				if !entry.Hash.IsZero() {
+					hash = entry.Hash.String()[:7]
+				}
+				_, _ = fmt.Fprintf(&b, "\n   %s %s: %s [%s]", hash, entry.Type, entry.Summary, rule)
+			}
+		}
+	}
+
+	if c.Bump == versions.BumpMajor {
+		b.WriteString("\nUse --allow-major if the major release is intended")
+	}
+
+	return errors.New(b.String())
+}
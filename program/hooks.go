@@ -0,0 +1,102 @@
+package program
+
+import (
+	"fmt"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/rs/zerolog/log"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// Hooks are shell commands run at points in tagging or making a release.  A command which fails stops the steps after
+// it.
+type Hooks struct {
+	BeforeReplace []string `group:"hooks" sep:"none" placeholder:"COMMAND" help:"run this command before the version is written into files"`
+	AfterFiles    []string `group:"hooks" sep:"none" placeholder:"COMMAND" help:"run this command after the files (and changelog) are updated"`
+	BeforeTag     []string `group:"hooks" sep:"none" placeholder:"COMMAND" help:"run this command before the tag is created"`
+	AfterTag      []string `group:"hooks" sep:"none" placeholder:"COMMAND" help:"run this command after the tag is created, before it is pushed"`
+}
+
+// Names of the points at which hooks run, given to them as $CHANGETOOL_HOOK
+const (
+	HookBeforeReplace = "before-replace"
+	HookAfterFiles    = "after-files"
+	HookBeforeTag     = "before-tag"
+	HookAfterTag      = "after-tag"
+)
+
+// hookEnv describes the release to the hook commands
+type hookEnv struct {
+	// Dir is the directory in which the commands run, the root of the worktree
+	Dir       string
+	Calc      *Calculation
+	Changelog string
+}
+
+// newHookEnv describes the calculation to hooks run at the root of the repository's worktree
+func newHookEnv(r *repo.Repository, calc *Calculation, changelog string) (hookEnv, error) {
+	w, err := r.Worktree()
+	if err != nil {
+		return hookEnv{}, err
+	}
+
+	return hookEnv{Dir: w.Filesystem.Root(), Calc: calc, Changelog: changelog}, nil
+}
+
+// environment returns the environment of the hook commands
+func (e hookEnv) environment(point string) []string {
+	return append(os.Environ(),
+		"CHANGETOOL_HOOK="+point,
+		"CHANGETOOL_VERSION="+e.Calc.next(),
+		"CHANGETOOL_PREVIOUS_VERSION="+e.Calc.previous(),
+		"CHANGETOOL_TAG="+e.Calc.tag(),
+		"CHANGETOOL_CHANGELOG="+e.Changelog,
+	)
+}
+
+// commands returns the commands to run at the point
+func (h *Hooks) commands(point string) []string {
+	switch point {
+	case HookBeforeReplace:
+		return h.BeforeReplace
+	case HookAfterFiles:
+		return h.AfterFiles
+	case HookBeforeTag:
+		return h.BeforeTag
+	case HookAfterTag:
+		return h.AfterTag
+	default:
+		return nil
+	}
+}
+
+// run runs the commands of the hook point in order, stopping at the first which fails.  Their output goes to stderr,
+// leaving stdout for the program's own output.
+func (h *Hooks) run(point string, env hookEnv) error {
+	for _, command := range h.commands(point) {
+		log.Info().Str("hook", point).Str("command", command).Msg("Running hook")
+
+		cmd := shellCommand(command)
+		cmd.Dir = env.Dir
+		cmd.Env = env.environment(point)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s hook '%s' failed: %w", point, command, err)
+		}
+	}
+
+	return nil
+}
+
+// shellCommand returns the command which runs the command line with the system's shell
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		// #nosec G204
+		return exec.Command("cmd", "/C", command)
+	}
+	// #nosec G204
+	return exec.Command("sh", "-c", command)
+}
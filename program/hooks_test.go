@@ -0,0 +1,132 @@
+package program
+
+import (
+	"github.com/deweysasser/changetool/test_framework"
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"testing"
+)
+
+// logHook is a hook command which appends its environment to hooks.log, beside the repository
+const logHook = `echo "$CHANGETOOL_HOOK $CHANGETOOL_VERSION $CHANGETOOL_PREVIOUS_VERSION $CHANGETOOL_TAG $(basename "$CHANGETOOL_CHANGELOG")" >> ../hooks.log`
+
+func TestSemverHooks(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat: added a feat"}, 0))
+
+	hooksLog := path.Join(r.Path, "..", "hooks.log")
+	_ = os.Remove(hooksLog)
+
+	t.Run("failing hook", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag", "--before-tag", "exit 3")
+		assert.EqualError(t, err, "before-tag hook 'exit 3' failed: exit status 3")
+		_, err = r.Tag("v1.3.0")
+		assert.ErrorIs(t, err, git.ErrTagNotFound)
+	})
+
+	allHooks := []string{"--before-replace", logHook, "--after-files", logHook, "--before-tag", logHook, "--after-tag", logHook}
+
+	t.Run("no hooks without files or tag", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, append([]string{"semver", "--allow-untracked", "--explain"}, allHooks...)...)
+		assert.NoError(t, err)
+		assert.Contains(t, out, "1.3.0")
+
+		_, err = os.Stat(hooksLog)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("hooks", func(t *testing.T) {
+		versionFile := path.Join(test_framework.TestDir(t), "VERSION")
+		must(t, os.WriteFile(versionFile, []byte("1.2.0\n"), 0600))
+
+		_, err := runCommand(t, r.Path, append([]string{"semver", "--allow-untracked", "--tag", "--replace-in", versionFile}, allHooks...)...)
+		assert.NoError(t, err)
+
+		bytes, err := os.ReadFile(hooksLog)
+		must(t, err)
+		assert.Equal(t, `before-replace 1.3.0 1.2.0 v1.3.0 
+after-files 1.3.0 1.2.0 v1.3.0 
+before-tag 1.3.0 1.2.0 v1.3.0 
+after-tag 1.3.0 1.2.0 v1.3.0 
+`, string(bytes))
+	})
+}
+
+func TestReleaseHooks(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat: added a feat"}, 0))
+
+	hooksLog := path.Join(r.Path, "..", "hooks.log")
+	_ = os.Remove(hooksLog)
+
+	head, err := r.Head()
+	must(t, err)
+
+	w, err := r.Worktree()
+	must(t, err)
+
+	t.Run("failing hook rolls back", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "release", "--after-files", "echo generated >> test1.c", "--before-tag", "exit 1")
+		assert.Error(t, err)
+
+		after, err := r.Head()
+		must(t, err)
+		assert.Equal(t, head.Hash(), after.Hash())
+
+		_, err = os.Stat(path.Join(r.Path, "CHANGELOG.md"))
+		assert.True(t, os.IsNotExist(err))
+
+		status, err := w.Status()
+		must(t, err)
+		assert.True(t, status.IsClean(), status.String())
+	})
+
+	t.Run("failing hook keeps untracked files", func(t *testing.T) {
+		untracked := path.Join(r.Path, "notes.txt")
+		must(t, os.WriteFile(untracked, []byte("work in progress\n"), 0600))
+
+		_, err := runCommand(t, r.Path, "release", "--allow-untracked", "--after-files", "echo generated >> test1.c", "--before-tag", "exit 1")
+		assert.Error(t, err)
+
+		after, err := r.Head()
+		must(t, err)
+		assert.Equal(t, head.Hash(), after.Hash())
+
+		bytes, err := os.ReadFile(untracked)
+		must(t, err)
+		assert.Equal(t, "work in progress\n", string(bytes))
+
+		status, err := w.Status()
+		must(t, err)
+		assert.Len(t, status, 1, status.String())
+		assert.Equal(t, git.Untracked, status.File("notes.txt").Worktree)
+
+		must(t, os.Remove(untracked))
+	})
+
+	t.Run("hooks", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "release", "--after-files", "echo generated >> test1.c", "--after-files", logHook, "--after-tag", logHook)
+		assert.NoError(t, err)
+
+		bytes, err := os.ReadFile(hooksLog)
+		must(t, err)
+		assert.Equal(t, "after-files 1.3.0 1.2.0 v1.3.0 CHANGELOG.md\nafter-tag 1.3.0 1.2.0 v1.3.0 CHANGELOG.md\n", string(bytes))
+
+		// the files the hooks changed are part of the release commit
+		status, err := w.Status()
+		must(t, err)
+		assert.True(t, status.IsClean(), status.String())
+
+		bytes, err = os.ReadFile(path.Join(r.Path, "test1.c"))
+		must(t, err)
+		assert.Contains(t, string(bytes), "generated\n")
+	})
+}
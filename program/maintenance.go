@@ -0,0 +1,176 @@
+package program
+
+import (
+	"fmt"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/versions"
+	"github.com/rs/zerolog/log"
+	"regexp"
+	"strings"
+)
+
+// Maintenance maps branches to the maintenance lines of versions released from them
+type Maintenance struct {
+	MaintenanceBranch []string `group:"maintenance" sep:"none" default:"release/{major}.{minor}" placeholder:"PATTERN[=LINE]" help:"branches matching PATTERN release only versions of LINE, e.g. 'release/{major}.{minor}' or 'legacy=1.x'.  {major} and {minor} in PATTERN give the line when it is not given, and * matches any text without /"`
+	MaintenancePolicy string   `group:"maintenance" enum:"cap,fail" default:"cap" help:"what to do on a maintenance branch when the changes call for a bigger bump than its line allows (cap|fail)"`
+	Branch            string   `group:"maintenance" placeholder:"NAME" help:"the branch being released, instead of the branch checked out (e.g. when CI checks out a detached HEAD)"`
+}
+
+// maintenanceRule is a compiled --maintenance-branch
+type maintenanceRule struct {
+	pattern *regexp.Regexp
+	// line is the line given after "=", if any
+	line *versions.Line
+}
+
+var placeholderRegexp = regexp.MustCompile(`\{major}|\{minor}|\*`)
+
+// parseMaintenanceRule compiles a PATTERN[=LINE] rule
+func parseMaintenanceRule(s string) (maintenanceRule, error) {
+	pattern, lineSpec := s, ""
+	if n := strings.LastIndex(s, "="); n >= 0 {
+		pattern, lineSpec = s[:n], s[n+1:]
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range placeholderRegexp.FindAllStringIndex(pattern, -1) {
+		expr.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		switch pattern[loc[0]:loc[1]] {
+		case "{major}":
+			expr.WriteString(`(?P<major>[0-9]+)`)
+		case "{minor}":
+			expr.WriteString(`(?P<minor>[0-9]+)`)
+		default:
+			expr.WriteString(`[^/]*`)
+		}
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+	expr.WriteString("$")
+
+	rule := maintenanceRule{}
+
+	var err error
+	if rule.pattern, err = regexp.Compile(expr.String()); err != nil {
+		return rule, fmt.Errorf("invalid maintenance branch %s: %w", s, err)
+	}
+
+	switch {
+	case lineSpec != "":
+		line, err := versions.ParseLine(lineSpec)
+		if err != nil {
+			return rule, fmt.Errorf("invalid maintenance branch %s: %w", s, err)
+		}
+		rule.line = &line
+	case rule.pattern.SubexpIndex("major") < 0:
+		return rule, fmt.Errorf("invalid maintenance branch %s: the pattern needs {major} or an =LINE", s)
+	}
+
+	return rule, nil
+}
+
+// match returns the line of the branch, or nil if the rule does not apply to it
+func (m maintenanceRule) match(branch string) (*versions.Line, error) {
+	found := m.pattern.FindStringSubmatch(branch)
+	if found == nil {
+		return nil, nil
+	}
+
+	if m.line != nil {
+		return m.line, nil
+	}
+
+	spec := found[m.pattern.SubexpIndex("major")]
+	if n := m.pattern.SubexpIndex("minor"); n >= 0 {
+		spec += "." + found[n]
+	}
+
+	line, err := versions.ParseLine(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &line, nil
+}
+
+// branch returns the name of the branch being released:  --branch, or the branch checked out.  It is "" when HEAD is
+// detached.
+func (m *Maintenance) branch(r *repo.Repository) (string, error) {
+	if m.Branch != "" {
+		return m.Branch, nil
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return "", err
+	}
+
+	if !head.Name().IsBranch() {
+		return "", nil
+	}
+	return head.Name().Short(), nil
+}
+
+// maintenanceLine returns the line of the branch being released and the branch, or a nil line if it is not a
+// maintenance branch.  The first rule matching the branch applies.
+func (m *Maintenance) maintenanceLine(r *repo.Repository) (*versions.Line, string, error) {
+	branch, err := m.branch(r)
+	if err != nil || branch == "" {
+		return nil, branch, err
+	}
+
+	for _, spec := range m.MaintenanceBranch {
+		if spec == "" {
+			continue
+		}
+
+		rule, err := parseMaintenanceRule(spec)
+		if err != nil {
+			return nil, branch, err
+		}
+
+		line, err := rule.match(branch)
+		if err != nil {
+			return nil, branch, err
+		}
+		if line != nil {
+			log.Debug().Str("branch", branch).Str("line", line.String()).Msg("Maintenance branch")
+			return line, branch, nil
+		}
+	}
+
+	return nil, branch, nil
+}
+
+// capBump limits the bump of the calculation to what its maintenance line allows, or fails if the policy or an
+// explicit --bump rules that out
+func (m *Maintenance) capBump(calc *Calculation) error {
+	if calc.Line == nil || calc.Bump <= calc.Line.MaxBump() {
+		return nil
+	}
+
+	if calc.BumpSetBy != "" || m.MaintenancePolicy == "fail" {
+		return fmt.Errorf("%s exceeds maintenance line %s of branch %s, which allows at most a %s bump",
+			calc.describeBump(), calc.Line, calc.Branch, calc.Line.MaxBump())
+	}
+
+	log.Info().Str("line", calc.Line.String()).Str("bump", calc.Bump.String()).
+		Str("cap", calc.Line.MaxBump().String()).Msg("Capping the bump for the maintenance line")
+
+	calc.CappedFrom = calc.Bump
+	calc.Bump = calc.Line.MaxBump()
+	return nil
+}
+
+// describeBump describes the bump and what decided it, for messages
+func (c *Calculation) describeBump() string {
+	switch {
+	case c.BumpSetBy != "":
+		return fmt.Sprintf("the %s bump set by %s", c.Bump, c.BumpSetBy)
+	case c.PreviousTag != "":
+		return fmt.Sprintf("the %s bump the changes since %s call for", c.Bump, c.PreviousTag)
+	default:
+		return fmt.Sprintf("the %s bump the changes call for", c.Bump)
+	}
+}
@@ -0,0 +1,99 @@
+package program
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// VersionOutput is the machine readable result of a version calculation
+type VersionOutput struct {
+	PreviousVersion string `json:"previous_version"`
+	PreviousTag     string `json:"previous_tag"`
+	NextVersion     string `json:"next_version"`
+	Bump            string `json:"bump"`
+	ReleaseNeeded   bool   `json:"release_needed"`
+	Major           int64  `json:"major"`
+	Minor           int64  `json:"minor"`
+	Patch           int64  `json:"patch"`
+	Prerelease      string `json:"prerelease"`
+	CommitCount     int    `json:"commit_count"`
+	Head            string `json:"head"`
+}
+
+// NewVersionOutput creates the machine readable form of the calculation
+func NewVersionOutput(calc *Calculation) VersionOutput {
+	return VersionOutput{
+		PreviousVersion: calc.previous(),
+		PreviousTag:     calc.PreviousTag,
+		NextVersion:     calc.next(),
+		Bump:            calc.Bump.String(),
+		ReleaseNeeded:   calc.ReleaseNeeded(),
+		Major:           calc.NextVersion.Major(),
+		Minor:           calc.NextVersion.Minor(),
+		Patch:           calc.NextVersion.Patch(),
+		Prerelease:      calc.NextVersion.Prerelease(),
+		CommitCount:     calc.Changes.Count,
+		Head:            calc.Head.String(),
+	}
+}
+
+// pairs returns the output as ordered key/value pairs, with keys named as in the JSON form
+func (o VersionOutput) pairs() [][2]string {
+	return [][2]string{
+		{"previous_version", o.PreviousVersion},
+		{"previous_tag", o.PreviousTag},
+		{"next_version", o.NextVersion},
+		{"bump", o.Bump},
+		{"release_needed", fmt.Sprint(o.ReleaseNeeded)},
+		{"major", fmt.Sprint(o.Major)},
+		{"minor", fmt.Sprint(o.Minor)},
+		{"patch", fmt.Sprint(o.Patch)},
+		{"prerelease", o.Prerelease},
+		{"commit_count", fmt.Sprint(o.CommitCount)},
+		{"head", o.Head},
+	}
+}
+
+// WriteJSON writes the output as a JSON object
+func (o VersionOutput) WriteJSON(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(o)
+}
+
+// WriteEnv writes the output as KEY=value lines suitable for sourcing in a shell or a .env file
+func (o VersionOutput) WriteEnv(out io.Writer) error {
+	for _, p := range o.pairs() {
+		if _, err := fmt.Fprintf(out, "%s=%s\n", strings.ToUpper(p[0]), p[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteGithub appends the output to the file named by $GITHUB_OUTPUT, for use as github actions step outputs
+func (o VersionOutput) WriteGithub() error {
+	filename := os.Getenv("GITHUB_OUTPUT")
+	if filename == "" {
+		return errors.New("GITHUB_OUTPUT is not set")
+	}
+
+	// #nosec G302 G304
+	fp, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range o.pairs() {
+		if _, err = fmt.Fprintf(fp, "%s=%s\n", p[0], p[1]); err != nil {
+			_ = fp.Close()
+			return err
+		}
+	}
+
+	return fp.Close()
+}
@@ -5,12 +5,14 @@ import (
 	"github.com/alecthomas/kong"
 	"github.com/deweysasser/changetool/changes"
 	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/versions"
 	"github.com/mattn/go-colorable"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"io"
 	"os"
 	"runtime"
+	"strings"
 )
 
 // Options is the structure of program options
@@ -25,6 +27,10 @@ type Options struct {
 	Changelog  Changelog  `cmd:"" help:"calculate changelogs"`
 	VersionCmd VersionCmd `name:"version" cmd:"" help:"show program version"`
 	Semver     Semver     `cmd:"" help:"Manipulate Semantic Versions"`
+	GoModules  GoModules  `name:"go-modules" cmd:"" help:"Calculate versions for each go module in the repository"`
+	Release    Release    `cmd:"" help:"Update version files and the changelog, commit and tag the next version"`
+	Backfill   Backfill   `cmd:"" help:"Propose, and with --apply create, version tags for past releases which were never tagged"`
+	Audit      Audit      `cmd:"" help:"Check that past release tags have the versions their commits call for"`
 
 	OutFP *os.File `kong:"-"`
 }
@@ -35,7 +41,11 @@ func (program *Options) Parse(args []string) (*kong.Context, error) {
 		kong.Description("Brief Program Summary"),
 		kong.ShortUsageOnError(),
 		kong.Vars{
-			"type_order": changes.TypesInOrder.Join(","),
+			"type_order":             changes.TypesInOrder.Join(","),
+			"version_formats":        strings.Join(versions.FormatNames(), ","),
+			"version_schemes":        strings.Join(versions.SchemeNames(), ","),
+			"calver_format":          versions.DefaultCalVerFormat,
+			"release_commit_pattern": ReleaseCommitPattern,
 		},
 	)
 	if err != nil {
@@ -0,0 +1,101 @@
+package program
+
+import (
+	"errors"
+	"fmt"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/rs/zerolog/log"
+)
+
+// remoteTag returns the commit to which the tag on the remote points, or the zero hash if the remote does not have the
+// tag
+func (t *Tagging) remoteTag(r *repo.Repository, name string) (plumbing.Hash, error) {
+	remote, err := r.Remote(t.Remote)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("remote %s: %w", t.Remote, err)
+	}
+
+	refs, err := remote.List(&git.ListOptions{})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return plumbing.ZeroHash, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to list the references of remote %s: %w", t.Remote, err)
+	}
+
+	for _, ref := range refs {
+		if ref.Name() != plumbing.NewTagReferenceName(name) {
+			continue
+		}
+
+		target, err := peel(r, ref.Hash())
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("tag %s exists on remote %s, but its target is unknown (fetch it first): %w", name, t.Remote, err)
+		}
+		return target, nil
+	}
+
+	return plumbing.ZeroHash, nil
+}
+
+// peel follows tag objects to the object they tag
+func peel(r *repo.Repository, hash plumbing.Hash) (plumbing.Hash, error) {
+	for {
+		tag, err := r.TagObject(hash)
+		switch {
+		case errors.Is(err, plumbing.ErrObjectNotFound):
+			if _, err = r.Object(plumbing.AnyObject, hash); err != nil {
+				return plumbing.ZeroHash, err
+			}
+			return hash, nil
+		case err != nil:
+			return plumbing.ZeroHash, err
+		case tag.TargetType != plumbing.TagObject:
+			return tag.Target, nil
+		}
+		hash = tag.Target
+	}
+}
+
+// checkRemote refuses to tag the commit if the remote already has the tag pointing somewhere else.  The commit is the
+// zero hash when it is not yet known, in which case any existing remote tag conflicts.  It returns true if the remote
+// already has the tag on the commit.
+func (t *Tagging) checkRemote(r *repo.Repository, name string, commit plumbing.Hash) (bool, error) {
+	if !t.Push {
+		return false, nil
+	}
+
+	target, err := t.remoteTag(r, name)
+	switch {
+	case err != nil:
+		return false, err
+	case target.IsZero():
+		return false, nil
+	case target == commit:
+		log.Info().Str("tag", name).Str("remote", t.Remote).Msg("Remote already has the tag")
+		return true, nil
+	default:
+		return false, fmt.Errorf("tag %s already exists on remote %s, pointing to %s", name, t.Remote, target.String()[:7])
+	}
+}
+
+// push pushes the references to the remote, using the credentials in its URL or, for ssh, the ssh agent
+func (t *Tagging) push(r *repo.Repository, names ...plumbing.ReferenceName) error {
+	var specs []config.RefSpec
+	for _, name := range names {
+		specs = append(specs, config.RefSpec(fmt.Sprintf("%s:%s", name, name)))
+	}
+
+	log.Debug().Str("remote", t.Remote).Interface("refspecs", specs).Msg("Pushing")
+
+	err := r.Push(&git.PushOptions{RemoteName: t.Remote, RefSpecs: specs})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("unable to push to %s: %w", t.Remote, err)
+	}
+
+	return nil
+}
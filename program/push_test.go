@@ -0,0 +1,102 @@
+package program
+
+import (
+	"github.com/deweysasser/changetool/test_framework"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+// newRemote creates a bare repository and adds it to the repo as the "origin" remote
+func newRemote(t *testing.T, r *test_framework.MyRepo) *git.Repository {
+	dir, err := filepath.Abs(path.Join(test_framework.TestDir(t), "remote.git"))
+	must(t, err)
+	must(t, os.RemoveAll(dir))
+
+	bare, err := git.PlainInit(dir, true)
+	must(t, err)
+
+	_, err = r.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"file://" + filepath.ToSlash(dir)}})
+	must(t, err)
+
+	return bare
+}
+
+func TestSemverPush(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat: added a feat"}, 0))
+
+	remote := newRemote(t, r)
+
+	t.Run("needs tag", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--push")
+		assert.EqualError(t, err, "--push needs --tag")
+	})
+
+	t.Run("push tag", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag", "--push")
+		assert.NoError(t, err)
+
+		local, err := r.Tag("v1.3.0")
+		must(t, err)
+		pushed, err := remote.Tag("v1.3.0")
+		if assert.NoError(t, err) {
+			assert.Equal(t, local.Hash(), pushed.Hash())
+		}
+	})
+
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "fix: added a fix"}, 1))
+
+	t.Run("conflicting remote tag", func(t *testing.T) {
+		// someone else released v1.3.1 on another commit
+		v130, err := remote.Tag("v1.3.0")
+		must(t, err)
+		_, err = remote.CreateTag("v1.3.1", v130.Hash(), nil)
+		must(t, err)
+
+		_, err = runCommand(t, r.Path, "semver", "--allow-untracked", "--tag", "--push")
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "tag v1.3.1 already exists on remote origin")
+		}
+
+		_, err = r.Tag("v1.3.1")
+		assert.ErrorIs(t, err, git.ErrTagNotFound)
+	})
+}
+
+func TestReleasePush(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat: added a feat"}, 0))
+
+	remote := newRemote(t, r)
+
+	_, err = runCommand(t, r.Path, "release", "--push")
+	assert.NoError(t, err)
+
+	head, err := r.Head()
+	must(t, err)
+
+	branch, err := remote.Reference(head.Name(), false)
+	if assert.NoError(t, err) {
+		assert.Equal(t, head.Hash(), branch.Hash())
+	}
+
+	tag, err := remote.Tag("v1.3.0")
+	if assert.NoError(t, err) {
+		object, err := remote.TagObject(tag.Hash())
+		must(t, err)
+		assert.Equal(t, head.Hash(), object.Target)
+		assert.Equal(t, plumbing.CommitObject, object.TargetType)
+	}
+}
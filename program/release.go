@@ -0,0 +1,346 @@
+package program
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/deweysasser/changetool/changes"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/versionfile"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/rs/zerolog/log"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Release calculates the next version, writes it into files and the changelog, commits the result and tags the commit
+type Release struct {
+	Changelog
+	Tagging
+	Hooks
+	VersionScheme
+	Maintenance
+	Guard
+	FromFile       string   `group:"source" placeholder:"FILE[:SELECTOR]" help:"Set previous revision from the version in this file instead of from tags"`
+	FromFileOrTag  bool     `group:"source" help:"find the previous version from tags if the --from-file file has none"`
+	VerifyTags     string   `group:"source" placeholder:"KEYRING" help:"only trust the previous version tag if it is signed by a key in this OpenPGP key ring file"`
+	AllowUntracked bool     `group:"calculation" help:"allow untracked files to count as clean"`
+	SetVersion     string   `group:"calculation" placeholder:"VERSION" help:"release this version, which must be greater than the previous version, instead of calculating it"`
+	Bump           string   `group:"calculation" enum:",major,minor,patch,none" default:"" help:"bump the previous version by this level (major|minor|patch|none) instead of the level the changes call for.  'none' keeps the previous version, so there is nothing to release"`
+	ReplaceIn      []string `group:"locations" sep:"none" placeholder:"FILE[:SELECTOR]" help:"Replace version in these files (see 'semver --replace-in')"`
+	ChangelogFile  string   `group:"locations" default:"CHANGELOG.md" help:"add the release notes to the top of this file, relative to the worktree root.  Empty to skip"`
+	DryRun         bool     `group:"locations" help:"show the version and the diff of the files which would change, without changing anything"`
+}
+
+// releaseCommitMessage is the message of the commit which records a release
+func releaseCommitMessage(tag string) string {
+	return fmt.Sprintf("chore(release): %s", tag)
+}
+
+func (rel *Release) Run(program *Options) error {
+	r, err := program.Repository()
+	if err != nil {
+		return err
+	}
+
+	if err = rel.validate(); err != nil {
+		return err
+	}
+
+	if err = checkReplaceIn(rel.ReplaceIn); err != nil {
+		return err
+	}
+
+	s := Semver{
+		Changelog:      rel.Changelog,
+		FromFile:       rel.FromFile,
+		FromFileOrTag:  rel.FromFileOrTag,
+		VerifyTags:     rel.VerifyTags,
+		AllowUntracked: rel.AllowUntracked,
+		SetVersion:     rel.SetVersion,
+		Bump:           rel.Bump,
+		VersionScheme:  rel.VersionScheme,
+		Maintenance:    rel.Maintenance,
+		Guard:          rel.Guard,
+	}
+
+	calc, err := s.calculate(r)
+	if err != nil {
+		return err
+	}
+
+	if err = calc.guard(); err != nil {
+		return err
+	}
+
+	if len(calc.Dirty) > 0 {
+		return fmt.Errorf("the worktree has uncommitted changes: %s", strings.Join(calc.Dirty, ", "))
+	}
+
+	if !calc.ReleaseNeeded() {
+		return fmt.Errorf("no changes since version %s call for a release", calc.previous())
+	}
+
+	tag := tagName(calc.next())
+	if target, exists := r.TagMap()[tag]; exists {
+		return fmt.Errorf("tag %s already exists on commit %s.  If the tag is wrong, delete it with 'git tag -d %s'",
+			tag, target.String()[:7], tag)
+	}
+
+	if !rel.DryRun {
+		// The release commit does not exist yet, so any tag on the remote conflicts
+		if _, err = rel.checkRemote(r, tag, plumbing.ZeroHash); err != nil {
+			return err
+		}
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+	root := w.Filesystem.Root()
+
+	fileChanges, err := versionfile.Prepare(rel.ReplaceIn, calc.next())
+	if err != nil {
+		return err
+	}
+
+	env := hookEnv{Dir: root, Calc: calc}
+
+	if rel.ChangelogFile != "" {
+		change, err := rel.changelogChange(root, tag, calc.Changes)
+		if err != nil {
+			return err
+		}
+		fileChanges = append(fileChanges, change)
+		if env.Changelog, err = filepath.Abs(change.Path); err != nil {
+			return err
+		}
+	}
+
+	_, _ = fmt.Fprintln(program.OutFP, calc.next())
+
+	if rel.DryRun {
+		diff, err := versionfile.Diff(fileChanges)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(program.OutFP, diff)
+		return err
+	}
+
+	var names []string
+	for _, c := range fileChanges {
+		if !c.Changed() {
+			continue
+		}
+		name, err := worktreePath(root, c.Path)
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+
+	if err = rel.run(HookBeforeReplace, env); err != nil {
+		return err
+	}
+
+	if err = versionfile.WriteAll(fileChanges); err != nil {
+		return err
+	}
+
+	if err = rel.commitAndTag(r, w, names, env); err != nil {
+		rollback(r, w, calc.Head, fileChanges)
+		return err
+	}
+
+	log.Info().Str("tag", tag).Msg("Released")
+
+	if err = rel.run(HookAfterTag, env); err != nil {
+		return fmt.Errorf("%w (the release commit and tag %s were made)", err, tag)
+	}
+
+	if rel.Push {
+		return rel.pushRelease(r, tag)
+	}
+
+	return nil
+}
+
+// pushRelease pushes the branch holding the release commit and the release tag
+func (rel *Release) pushRelease(r *repo.Repository, tag string) error {
+	head, err := r.Head()
+	if err != nil {
+		return err
+	}
+
+	refs := []plumbing.ReferenceName{plumbing.NewTagReferenceName(tag)}
+	if head.Name().IsBranch() {
+		refs = append([]plumbing.ReferenceName{head.Name()}, refs...)
+	} else {
+		log.Warn().Msg("HEAD is not a branch, pushing only the tag")
+	}
+
+	if err = rel.push(r, refs...); err != nil {
+		return fmt.Errorf("%w (the release commit and tag %s were made locally)", err, tag)
+	}
+
+	return nil
+}
+
+// changelogChange adds the release notes for the changes to the top of the changelog file
+func (rel *Release) changelogChange(root, tag string, changeSet *changes.ChangeSet) (versionfile.Change, error) {
+	path := rel.ChangelogFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+
+	change := versionfile.Change{Target: versionfile.Target{Path: path}, Mode: 0644}
+
+	// #nosec G304
+	original, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		change.Created = true
+	case err != nil:
+		return change, err
+	default:
+		info, err := os.Stat(path)
+		if err != nil {
+			return change, err
+		}
+		change.Mode = info.Mode().Perm()
+		change.Original = original
+	}
+
+	var b bytes.Buffer
+	_, _ = fmt.Fprintf(&b, "## %s (%s)\n\n", tag, time.Now().Format("2006-01-02"))
+	b.WriteString(rel.notes(changeSet))
+	b.Write(original)
+
+	change.Updated = b.Bytes()
+
+	return change, nil
+}
+
+// commitAndTag runs the after-files hooks, commits the named files (and any tracked files the hooks changed) as the
+// release, runs the before-tag hooks and tags the commit
+func (rel *Release) commitAndTag(r *repo.Repository, w *git.Worktree, names []string, env hookEnv) error {
+	if err := rel.run(HookAfterFiles, env); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if _, err := w.Add(name); err != nil {
+			return err
+		}
+	}
+
+	calc := env.Calc
+	tag := tagName(calc.next())
+
+	commit, err := w.Commit(releaseCommitMessage(tag), &git.CommitOptions{All: true})
+	if err != nil {
+		return err
+	}
+
+	log.Debug().Str("commit", commit.String()[:6]).Msg("Committed release")
+
+	if err = rel.run(HookBeforeTag, env); err != nil {
+		return err
+	}
+
+	return rel.createTag(r, calc, commit, rel.notes(calc.Changes))
+}
+
+// rollback returns the branch and the index to the previous head, which was clean, and restores or removes the files
+// which were changed.  Untracked files are left alone.
+func rollback(r *repo.Repository, w *git.Worktree, head plumbing.Hash, fileChanges []versionfile.Change) {
+	log.Warn().Str("head", head.String()[:6]).Msg("Release failed, rolling back")
+
+	if err := w.Reset(&git.ResetOptions{Commit: head, Mode: git.MixedReset}); err != nil {
+		log.Err(err).Msg("Unable to reset to the previous head")
+	}
+
+	versionfile.Restore(fileChanges)
+
+	if err := restoreTracked(r, w, head); err != nil {
+		log.Err(err).Msg("Unable to restore the files changed by hooks")
+	}
+}
+
+// restoreTracked writes back the content at head of the tracked files which are still changed, such as those the
+// hooks changed
+func restoreTracked(r *repo.Repository, w *git.Worktree, head plumbing.Hash) error {
+	status, err := w.Status()
+	if err != nil {
+		return err
+	}
+
+	commit, err := r.CommitObject(head)
+	if err != nil {
+		return err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	for name, s := range status {
+		if s.Worktree != git.Modified && s.Worktree != git.Deleted {
+			continue
+		}
+
+		file, err := tree.File(name)
+		if err != nil {
+			return err
+		}
+
+		content, err := file.Contents()
+		if err != nil {
+			return err
+		}
+
+		mode, err := file.Mode.ToOSFileMode()
+		if err != nil {
+			return err
+		}
+
+		log.Debug().Str("file", name).Msg("Restoring file")
+		// #nosec G306
+		if err = os.WriteFile(filepath.Join(w.Filesystem.Root(), filepath.FromSlash(name)), []byte(content), mode.Perm()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// worktreePath returns the path of the file relative to the root of the worktree
+func worktreePath(root, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(absRoot, abs)
+	if err != nil {
+		return "", err
+	}
+
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not within the worktree %s", path, root)
+	}
+
+	return filepath.ToSlash(rel), nil
+}
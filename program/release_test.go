@@ -0,0 +1,122 @@
+package program
+
+import (
+	"github.com/deweysasser/changetool/changes"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/test_framework"
+	"github.com/deweysasser/changetool/versionfile"
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestRelease(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{
+		Message:  "feat: added a feat",
+		Contents: map[string]string{"package.json": `{"version": "1.2.0"}` + "\n"},
+	}, 0))
+
+	packageJSON := path.Join(r.Path, "package.json")
+
+	out, err := runCommand(t, r.Path, "release", "--dry-run", "--replace-in", packageJSON)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "1.3.0\n--- "+packageJSON), out)
+	assert.Contains(t, out, "+## v1.3.0 (")
+	_, err = os.Stat(path.Join(r.Path, "CHANGELOG.md"))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = runCommand(t, r.Path, "release", "--bump", "none")
+	assert.EqualError(t, err, "no changes since version 1.2.0 call for a release")
+
+	out, err = runCommand(t, r.Path, "release", "--replace-in", packageJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0\n", out)
+
+	bytes, err := os.ReadFile(packageJSON)
+	must(t, err)
+	assert.Equal(t, `{"version": "1.3.0"}`+"\n", string(bytes))
+
+	bytes, err = os.ReadFile(path.Join(r.Path, "CHANGELOG.md"))
+	must(t, err)
+	assert.True(t, strings.HasPrefix(string(bytes), "## v1.3.0 ("), string(bytes))
+	assert.Contains(t, string(bytes), "Feature:\n   * added a feat\n")
+
+	head, err := r.Head()
+	must(t, err)
+	commit, err := r.CommitObject(head.Hash())
+	must(t, err)
+	assert.Equal(t, "chore(release): v1.3.0", commit.Message)
+
+	ref, err := r.Tag("v1.3.0")
+	must(t, err)
+	tag, err := r.TagObject(ref.Hash())
+	must(t, err)
+	assert.Equal(t, head.Hash(), tag.Target)
+
+	w, err := r.Worktree()
+	must(t, err)
+	status, err := w.Status()
+	must(t, err)
+	assert.True(t, status.IsClean(), status.String())
+
+	_, err = runCommand(t, r.Path, "release")
+	assert.EqualError(t, err, "no changes since version 1.3.0 call for a release")
+}
+
+func TestReleaseRollback(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{
+		Message:  "fix: added a fix",
+		Contents: map[string]string{"package.json": `{"version": "1.2.0"}` + "\n"},
+	}, 0))
+
+	rr, err := repo.New(r.Path)
+	must(t, err)
+
+	s := Semver{}
+	s.Order = changes.TypesInOrder
+	s.DefaultType = "fix"
+	calc, err := s.calculate(rr)
+	must(t, err)
+	assert.Equal(t, "1.2.1", calc.NextVersion.String())
+
+	// A conflicting tag makes the release fail after its commit
+	_, err = rr.CreateTag("v1.2.1", calc.Head, nil)
+	must(t, err)
+
+	packageJSON := path.Join(r.Path, "package.json")
+	fileChanges, err := versionfile.Prepare([]string{packageJSON}, "1.2.1")
+	must(t, err)
+	must(t, versionfile.WriteAll(fileChanges))
+
+	w, err := rr.Worktree()
+	must(t, err)
+
+	rel := Release{Changelog: s.Changelog}
+	err = rel.commitAndTag(rr, w, []string{"package.json"}, hookEnv{Dir: r.Path, Calc: calc})
+	assert.ErrorIs(t, err, git.ErrTagExists)
+
+	rollback(rr, w, calc.Head, fileChanges)
+
+	head, err := rr.Head()
+	must(t, err)
+	assert.Equal(t, calc.Head, head.Hash())
+
+	bytes, err := os.ReadFile(packageJSON)
+	must(t, err)
+	assert.Equal(t, `{"version": "1.2.0"}`+"\n", string(bytes))
+
+	status, err := w.Status()
+	must(t, err)
+	assert.True(t, status.IsClean(), status.String())
+}
@@ -0,0 +1,66 @@
+package program
+
+import (
+	"fmt"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/versions"
+	"time"
+)
+
+// VersionScheme selects how versions are numbered
+type VersionScheme struct {
+	Scheme       string `group:"scheme" enum:",${version_schemes}" default:"" help:"how versions are numbered (${version_schemes}).  Defaults to the changetool.scheme git config of the repository, or semver"`
+	CalverFormat string `group:"scheme" placeholder:"FORMAT" help:"format of calendar versions, e.g. YYYY.0M.MICRO or YY.0W.  Defaults to the changetool.calverFormat git config, or ${calver_format}"`
+	Date         string `group:"scheme" placeholder:"YYYY-MM-DD" help:"the date of the release for calendar versions, instead of the date of the HEAD commit"`
+
+	selected versions.Scheme
+}
+
+// versionScheme returns the version scheme selected by --scheme and --calver-format or, failing those, by the
+// repository's changetool.scheme and changetool.calverFormat git config
+func (s *VersionScheme) versionScheme(r *repo.Repository) (versions.Scheme, error) {
+	if s.selected != nil {
+		return s.selected, nil
+	}
+
+	name, format := s.Scheme, s.CalverFormat
+
+	cfg, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	section := cfg.Raw.Section("changetool")
+	if name == "" {
+		name = section.Option("scheme")
+	}
+	if format == "" {
+		format = section.Option("calverFormat")
+	}
+
+	scheme, err := versions.NewScheme(name, format)
+	if err != nil {
+		return nil, err
+	}
+
+	s.selected = scheme
+	return scheme, nil
+}
+
+// releaseDate returns the date of the release for calendar versions:  the --date given, or the date of the HEAD commit
+func (s *VersionScheme) releaseDate(r *repo.Repository, calc *Calculation) (time.Time, error) {
+	if s.Date != "" {
+		date, err := time.Parse("2006-01-02", s.Date)
+		if err != nil {
+			return date, fmt.Errorf("invalid --date %s: %w", s.Date, err)
+		}
+		return date, nil
+	}
+
+	commit, err := r.CommitObject(calc.Head)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return commit.Committer.When, nil
+}
@@ -1,25 +1,50 @@
 package program
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"github.com/Masterminds/semver"
 	"github.com/deweysasser/changetool/changes"
 	"github.com/deweysasser/changetool/perf"
 	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/versionfile"
 	"github.com/deweysasser/changetool/versions"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"time"
 )
 
 type Semver struct {
 	Changelog
-	FromFile       string   `group:"source" xor:"source" required:"" type:"existingfile" help:"Set previous revision from the first semver looking string found in this file"`
-	ReplaceIn      []string `group:"locations" type:"existingfile" placeholder:"FILE" help:"Replace version in these files"`
+	Tagging
+	Hooks
+	VersionScheme
+	Maintenance
+	Guard
+	FromFile       string   `group:"source" xor:"source" required:"" placeholder:"FILE[:SELECTOR]" help:"Set previous revision from the version in this file (the version key of structured files, otherwise the first semver looking string)"`
+	FromFileOrTag  bool     `group:"source" help:"find the previous version from tags if the --from-file file has none"`
+	VerifyTags     string   `group:"source" placeholder:"KEYRING" help:"only trust the previous version tag if it is signed by a key in this OpenPGP key ring file"`
+	ReplaceIn      []string `group:"locations" sep:"none" placeholder:"FILE[:SELECTOR]" help:"Replace version in these files.  JSON, YAML, TOML and XML files update only their version key, or the keys given by SELECTOR"`
 	Tag            bool     `group:"locations" short:"t" help:"run 'git tag' with the calculated semver"`
+	DryRun         bool     `group:"locations" help:"show the diff of files which --replace-in would change, without changing them or tagging"`
+	Force          bool     `group:"tagging" help:"tag even when no commits call for a release"`
 	AllowUntracked bool     `group:"calculation" help:"allow untracked files to count as clean"`
+	GoPseudo       bool     `group:"calculation" help:"print the go pseudo-version of HEAD, based on the previous version tag"`
+	SetVersion     string   `group:"calculation" placeholder:"VERSION" help:"use this version, which must be greater than the previous version, instead of calculating it.  Overrides any 'Release-As:' commit footer"`
+	Bump           string   `group:"calculation" enum:",major,minor,patch,none" default:"" help:"bump the previous version by this level (major|minor|patch|none) instead of the level the changes call for.  Overrides any 'Release-As:' commit footer"`
+	Candidates     bool     `group:"output" help:"print the next version for each bump level, marking the one the changes call for, instead of the version (text or json output only)"`
+	VersionFormat  string   `group:"output" enum:"${version_formats}" default:"semver" help:"print the version in the form used by a packaging ecosystem (${version_formats})"`
+	OutputFormat   string   `group:"output" enum:"text,json,env,github" default:"text" help:"how to report the calculation (text|json|env|github).  'github' appends to the $GITHUB_OUTPUT file"`
+	Explain        bool     `group:"output" help:"explain how the version was calculated instead of printing it (as text or json)"`
+	Check          string   `group:"check" xor:"check" placeholder:"VERSION" help:"fail unless VERSION is the version the changes call for"`
+	CheckFile      string   `group:"check" xor:"check" placeholder:"FILE[:SELECTOR]" help:"fail unless the version in FILE is the version the changes call for"`
 }
 
 func (s *Semver) Run(program *Options) error {
@@ -28,114 +53,529 @@ func (s *Semver) Run(program *Options) error {
 		return err
 	}
 
-	nextVersion, err := s.getNextVersion(r)
+	if s.GoPseudo {
+		if s.Tag {
+			return errors.New("cannot tag with a go pseudo-version")
+		}
+		return s.runGoPseudo(program, r)
+	}
+
+	if s.Push && !s.Tag {
+		return errors.New("--push needs --tag")
+	}
+
+	if s.VersionFormat != "semver" && s.OutputFormat != "text" {
+		return fmt.Errorf("--version-format %s can't be used with --output-format %s, which reports the semver", s.VersionFormat, s.OutputFormat)
+	}
+
+	if s.Candidates && s.OutputFormat != "text" && s.OutputFormat != "json" {
+		return fmt.Errorf("--candidates can't be used with --output-format %s, only with text or json", s.OutputFormat)
+	}
+
+	if s.Tag {
+		if err = s.validate(); err != nil {
+			return err
+		}
+	}
+
+	if err = checkReplaceIn(s.ReplaceIn); err != nil {
+		return err
+	}
+
+	calc, err := s.calculate(r)
 	if err != nil {
 		return err
 	}
 
+	if s.Check != "" || s.CheckFile != "" {
+		if err = calc.guard(); err != nil {
+			return err
+		}
+		return s.runCheck(program, calc)
+	}
+
+	if s.Candidates {
+		return s.writeCandidates(program.OutFP, calc)
+	}
+
+	violation := calc.guard()
+	if violation != nil && !s.Explain {
+		return violation
+	}
+
+	if err = s.writeResult(program.OutFP, calc); err != nil {
+		return err
+	}
+
+	if violation != nil {
+		// --explain reports the violation, but the version is not used
+		if s.Tag || len(s.ReplaceIn) > 0 {
+			return violation
+		}
+		return nil
+	}
+
+	needsTag := s.Tag
 	if s.Tag {
-		head, err := r.Head()
-		if err != nil {
+		if needsTag, err = s.needsTag(r, calc); err != nil {
+			return err
+		}
+	}
+
+	remoteHasTag := false
+	if s.Tag && !s.DryRun {
+		if remoteHasTag, err = s.checkRemote(r, calc.tag(), calc.Head); err != nil {
+			return err
+		}
+	}
+
+	env, err := newHookEnv(r, calc, "")
+	if err != nil {
+		return err
+	}
+
+	// the file hooks belong to writing files, not to printing the version
+	fileHooks := len(s.ReplaceIn) > 0 && !s.DryRun
+
+	if fileHooks {
+		if err = s.run(HookBeforeReplace, env); err != nil {
+			return err
+		}
+	}
+
+	if err = s.ReplaceInFiles(program.OutFP, s.ReplaceIn, calc.next()); err != nil {
+		return err
+	}
+
+	if fileHooks {
+		if err = s.run(HookAfterFiles, env); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case !s.Tag:
+		return nil
+	case s.DryRun:
+		log.Info().Str("tag", calc.tag()).Msg("Dry run, not tagging")
+		return nil
+	case needsTag:
+		if err = s.run(HookBeforeTag, env); err != nil {
+			return err
+		}
+		if err = s.createTag(r, calc, calc.Head, s.notes(calc.Changes)); err != nil {
+			return err
+		}
+		if err = s.run(HookAfterTag, env); err != nil {
 			return err
 		}
-		_, err = r.CreateTag(
-			fmt.Sprintf("v%s", nextVersion.String()),
-			head.Hash(),
-			&git.CreateTagOptions{Message: fmt.Sprintf("Tag version %s", nextVersion.String())},
-		)
+	}
+
+	if s.Push && !remoteHasTag {
+		if err = s.push(r, plumbing.NewTagReferenceName(calc.tag())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// needsTag decides whether HEAD should be tagged with the calculated version.  It is false if HEAD already has the
+// version tag, and an error if the tag is on another commit or (without --force) no commits call for a release.
+func (s *Semver) needsTag(r *repo.Repository, calc *Calculation) (bool, error) {
+	tag := tagName(calc.next())
+
+	onHead, err := existingTag(r, tag, calc.Head)
+	if err != nil {
+		return false, err
+	}
+
+	if !onHead && !calc.ReleaseNeeded() && calc.PreviousTag != "" && r.TagMap()[calc.PreviousTag] == calc.Head {
+		// e.g. HEAD is tagged "v1.2", which is version 1.2.0
+		tag, onHead = calc.PreviousTag, true
+	}
+
+	switch {
+	case onHead:
+		log.Info().Str("tag", tag).Msg("HEAD is already tagged with the version")
+		calc.existingTag = tag
+		return false, nil
+	case !calc.commitsCallForRelease() && !s.Force:
+		since := calc.PreviousTag
+		if since == "" {
+			since = "version " + calc.previous()
+		}
+		return false, fmt.Errorf("no commits since %s call for a release, so there is nothing to tag.  "+
+			"Use --force to tag %s anyway", since, tag)
+	default:
+		return true, nil
+	}
+}
+
+// runCheck compares the version given by --check or --check-file with the calculated version
+func (s *Semver) runCheck(program *Options, calc *Calculation) error {
+	var supplied semver.Version
+
+	if s.CheckFile != "" {
+		v, err := versionfile.ReadVersion(s.CheckFile, calc.Scheme)
 		if err != nil {
 			return err
 		}
+		supplied = v
+	} else {
+		v, err := calc.Scheme.Parse(s.Check)
+		if err != nil {
+			return fmt.Errorf("invalid version %s: %w", s.Check, err)
+		}
+		supplied = v
 	}
 
-	_, _ = fmt.Fprintln(program.OutFP, nextVersion.String())
+	if err := checkVersion(calc, supplied); err != nil {
+		return err
+	}
 
-	for _, f := range s.ReplaceIn {
-		if err = s.ReplaceInFile(f, nextVersion.String()); err != nil {
+	_, _ = fmt.Fprintf(program.OutFP, "%s matches the calculated version %s\n", calc.format(supplied), calc.next())
+	return nil
+}
+
+// writeResult reports the calculation in the selected output format
+func (s *Semver) writeResult(out io.Writer, calc *Calculation) error {
+	if s.Explain {
+		if s.OutputFormat == "json" {
+			return s.explain(calc).WriteJSON(out)
+		}
+		return s.explain(calc).WriteText(out)
+	}
+
+	switch s.OutputFormat {
+	case "json":
+		return NewVersionOutput(calc).WriteJSON(out)
+	case "env":
+		return NewVersionOutput(calc).WriteEnv(out)
+	case "github":
+		return NewVersionOutput(calc).WriteGithub()
+	default:
+		if calc.Scheme.Name() != "semver" {
+			if s.VersionFormat != "semver" {
+				return fmt.Errorf("--version-format %s needs the semver scheme, not %s", s.VersionFormat, calc.Scheme.Name())
+			}
+			_, _ = fmt.Fprintln(out, calc.next())
+			return nil
+		}
+
+		formatted, err := versions.Format(s.VersionFormat, calc.NextVersion)
+		if err != nil {
 			return err
 		}
+
+		for _, v := range formatted {
+			_, _ = fmt.Fprintln(out, v)
+		}
+		return nil
+	}
+}
+
+// runGoPseudo prints the go pseudo-version of HEAD, or the version of HEAD if it is tagged
+func (s *Semver) runGoPseudo(program *Options, r *repo.Repository) error {
+	head, err := r.Head()
+	if err != nil {
+		return err
+	}
+
+	commit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+
+	version, foundTag, err := versions.FindPreviousGoVersion(r)
+	if err != nil {
+		return err
+	}
+
+	var pseudo string
+	switch {
+	case foundTag != "" && r.TagMap()[foundTag] == head.Hash():
+		pseudo = "v" + version.String()
+	case foundTag != "":
+		pseudo = versions.GoPseudoVersion("", &version, commit)
+	default:
+		pseudo = versions.GoPseudoVersion(goModuleMajor(r), nil, commit)
+	}
+
+	_, _ = fmt.Fprintln(program.OutFP, pseudo)
+
+	if err = s.ReplaceInFiles(program.OutFP, s.ReplaceIn, pseudo); err != nil {
+		return err
 	}
 
 	return nil
 }
 
-func (s *Semver) getNextVersion(r *repo.Repository) (semver.Version, error) {
+// goModuleMajor returns the major version suffix (e.g. "v2") of the module declared in the worktree's root go.mod,
+// or "" if there is none
+func goModuleMajor(r *repo.Repository) string {
+	w, err := r.Worktree()
+	if err != nil {
+		return ""
+	}
+
+	bytes, err := os.ReadFile(filepath.Join(w.Filesystem.Root(), "go.mod"))
+	if err != nil {
+		return ""
+	}
+
+	if _, pathMajor, ok := module.SplitPathVersion(modfile.ModulePath(bytes)); ok && pathMajor != "" {
+		return pathMajor[1:]
+	}
+
+	return ""
+}
+
+// Calculation records how the next version was determined
+type Calculation struct {
+	PreviousVersion semver.Version
+	PreviousTag     string
+	NextVersion     semver.Version
+	Bump            versions.Bump
+	Changes         *changes.ChangeSet
+	Head            plumbing.Hash
+	// Dirty lists the files which keep the worktree from being clean
+	Dirty []string
+	// DirtyBump is the bump made beyond the next version because the worktree is dirty
+	DirtyBump versions.Bump
+
+	// BumpSetBy describes what set the bump level instead of the changes, e.g. "--bump", if anything did
+	BumpSetBy string
+	// Calculated is the bump level the changes call for, whatever the bump is
+	Calculated versions.Bump
+	// SetBy describes what set the version instead of the changes, e.g. a "Release-As:" footer, if anything did
+	SetBy string
+
+	// Scheme is the way versions are numbered
+	Scheme versions.Scheme
+	// date is the date of the release, for calendar versions
+	date time.Time
+
+	// Line is the maintenance line of the branch being released, if it is a maintenance branch
+	Line   *versions.Line
+	Branch string
+	// CappedFrom is the bump the changes call for when it was capped to what the maintenance line allows
+	CappedFrom versions.Bump
+	// Violation is why the next version is beyond the limits of --max-version or --max-bump, if it is
+	Violation string
+
+	// existingTag is the tag HEAD already has for the version, if any
+	existingTag string
+}
+
+// tag returns the name of the tag for the calculated version, preferring the one HEAD already has
+func (c *Calculation) tag() string {
+	if c.existingTag != "" {
+		return c.existingTag
+	}
+	return tagName(c.next())
+}
+
+// next returns the next version as its scheme writes it
+func (c *Calculation) next() string {
+	return c.format(c.NextVersion)
+}
+
+// previous returns the previous version as its scheme writes it
+func (c *Calculation) previous() string {
+	return c.format(c.PreviousVersion)
+}
+
+// format writes the version in the scheme of the calculation
+func (c *Calculation) format(v semver.Version) string {
+	if c.Scheme == nil {
+		return v.String()
+	}
+	return c.Scheme.Format(v)
+}
+
+// ReleaseNeeded is true if the changes since the previous version, or uncommitted changes in the worktree, call for a
+// new release
+func (c *Calculation) ReleaseNeeded() bool {
+	return c.commitsCallForRelease() || len(c.Dirty) > 0
+}
+
+// commitsCallForRelease is true if the commits since the previous version call for a new release
+func (c *Calculation) commitsCallForRelease() bool {
+	return c.Bump != versions.BumpNone || c.SetBy != ""
+}
+
+func (s *Semver) calculate(r *repo.Repository) (*Calculation, error) {
+	scheme, err := s.versionScheme(r)
+	if err != nil {
+		return nil, err
+	}
+
+	line, branch, err := s.maintenanceLine(r)
+	if err != nil {
+		return nil, err
+	}
+
 	version, foundTag, err := s.FindPreviousVersion(r)
 
 	if err != nil {
-		return semver.Version{}, err
+		return nil, err
 	}
 
 	log.Debug().
 		Str("previous_version", version.String()).
 		Msg("Found previous version")
 
+	if s.VerifyTags != "" && foundTag != "" {
+		if err = verifyTag(r, foundTag, s.VerifyTags); err != nil {
+			return nil, err
+		}
+		log.Debug().Str("tag", foundTag).Msg("Previous version tag is signed")
+	}
+
 	if s.SinceTag == "" {
 		s.SinceTag = foundTag
 	}
 
 	changeSet, err := s.CalculateChanges(r)
 	if err != nil {
-		return semver.Version{}, err
+		return nil, err
 	}
 
-	nextVersion, err2 := s.findNextVersion(version, r, changeSet)
+	calc := &Calculation{
+		PreviousVersion: version,
+		PreviousTag:     foundTag,
+		Changes:         changeSet,
+		Scheme:          scheme,
+		Line:            line,
+		Branch:          branch,
+	}
 
-	if err2 != nil {
-		return semver.Version{}, err2
+	if err = s.findNextVersion(calc, r); err != nil {
+		return nil, err
 	}
-	return nextVersion, nil
+
+	return calc, nil
 }
 
-func (s *Semver) findNextVersion(version semver.Version, r *repo.Repository, changes *changes.ChangeSet) (semver.Version, error) {
+// findNextVersion fills in the next version of the calculation from its previous version and changes
+func (s *Semver) findNextVersion(calc *Calculation, r *repo.Repository) error {
 
-	status, head, err := s.gitWorktreeStatus(r)
+	status, head, err := gitWorktreeStatus(r)
 	if err != nil {
-		return semver.Version{}, err
+		return err
 	}
 
-	nextVersion := version
+	calc.Head = head.Hash()
+
+	date, err := s.releaseDate(r, calc)
+	if err != nil {
+		return err
+	}
+	calc.date = date
+
+	nextVersion := calc.PreviousVersion
 	nextVersion, _ = nextVersion.SetPrerelease("")
 	nextVersion, _ = nextVersion.SetMetadata("")
 
 	log.Debug().
-		Str("base_version", version.String()).
+		Str("base_version", calc.PreviousVersion.String()).
 		Msg("Base version")
 
-	var isClean bool
+	calc.Dirty = dirtyFiles(status, s.AllowUntracked)
 
-	if s.AllowUntracked {
-		clean := true
+	log.Debug().Str("status", status.String()).Msg("working directory clean status")
 
-		for f, file := range status {
-			if file.Worktree != git.Untracked {
-				clean = false
-			}
-			log.Debug().Str("file", f).
-				Int8("worktree_status", int8(file.Worktree)).
-				Int8("staging_status", int8(file.Staging)).
-				Msg("File status")
+	calc.Bump = bumpFromChangeSet(calc.Changes, nextVersion)
+	calc.Calculated = calc.Bump
+
+	if s.Bump != "" {
+		if calc.Bump, err = versions.ParseBump(s.Bump); err != nil {
+			return err
 		}
+		calc.BumpSetBy = "--bump"
+		log.Debug().Str("bump", calc.Bump.String()).Str("calculated", calc.Calculated.String()).Msg("Bump set")
+	}
 
-		isClean = clean
-	} else {
-		// FIXME:  this is a bit too aggressive
-		isClean = status.IsClean()
+	if err = s.capBump(calc); err != nil {
+		return err
 	}
 
-	log.Debug().Str("status", status.String()).Msg("working directory clean status")
+	if nextVersion, err = calc.Scheme.Next(nextVersion, calc.Bump, date); err != nil {
+		return err
+	}
 
-	nextVersion = nextVersionFromChangeSet(changes, nextVersion)
+	set, setBy, err := s.setVersion(calc.Scheme, calc.Changes)
+	if err != nil {
+		return err
+	}
 
-	if !isClean {
-		nextVersion = nextVersion.IncMinor()
-		return nextVersion.SetPrerelease(fmt.Sprintf("dirty.%s", head.Hash().String()[:6]))
-	} else {
-		return nextVersion, nil
+	if set != nil {
+		if !set.GreaterThan(&calc.PreviousVersion) {
+			return fmt.Errorf("version %s set by %s is not greater than the previous version %s", calc.format(*set), setBy, calc.previous())
+		}
+		log.Debug().Str("version", calc.format(*set)).Str("set_by", setBy).Msg("Version set")
+		calc.Bump = bumpBetween(calc.PreviousVersion, *set)
+		calc.SetBy = setBy
+		nextVersion = *set
 	}
+
+	if err = s.checkLimits(r, calc, nextVersion); err != nil {
+		return err
+	}
+
+	if len(calc.Dirty) > 0 {
+		// A dirty worktree is a prerelease of a version after the next one, if the scheme has one
+		dirtyBump := versions.BumpMinor
+		if calc.Line != nil && calc.Line.MaxBump() < dirtyBump {
+			dirtyBump = calc.Line.MaxBump()
+		}
+		if after, err := calc.Scheme.Next(nextVersion, dirtyBump, date); err == nil {
+			nextVersion = after
+			calc.DirtyBump = dirtyBump
+		}
+		nextVersion, err = nextVersion.SetPrerelease(fmt.Sprintf("dirty.%s", head.Hash().String()[:6]))
+		if err != nil {
+			return err
+		}
+	}
+
+	if calc.Line != nil && !calc.Line.Contains(nextVersion) {
+		return fmt.Errorf("version %s is outside maintenance line %s of branch %s", calc.format(nextVersion), calc.Line, calc.Branch)
+	}
+
+	calc.NextVersion = nextVersion
+	return nil
+}
+
+// setVersion returns the version given by --set-version or the most recent "Release-As:" footer, and what gave it, or
+// nil if the version is to be calculated
+func (s *Semver) setVersion(scheme versions.Scheme, changeSet *changes.ChangeSet) (*semver.Version, string, error) {
+	if s.SetVersion != "" && s.Bump != "" {
+		return nil, "", errors.New("--set-version and --bump can't be used together")
+	}
+
+	value, setBy := s.SetVersion, "--set-version"
+	if value == "" && s.Bump == "" {
+		var entry *changes.Entry
+		if value, entry = changeSet.ReleaseAs(); entry != nil {
+			setBy = fmt.Sprintf("Release-As footer in commit %s", entry.Hash.String()[:7])
+		}
+	}
+
+	if value == "" {
+		return nil, "", nil
+	}
+
+	v, err := scheme.Parse(value)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid version %s given by %s: %w", value, setBy, err)
+	}
+
+	return &v, setBy, nil
 }
 
-func (s *Semver) gitWorktreeStatus(r *repo.Repository) (git.Status, *plumbing.Reference, error) {
+func gitWorktreeStatus(r *repo.Repository) (git.Status, *plumbing.Reference, error) {
 	defer perf.Timer("getting worktree status").Stop()
 	w, err := r.Worktree()
 
@@ -157,76 +597,139 @@ func (s *Semver) gitWorktreeStatus(r *repo.Repository) (git.Status, *plumbing.Re
 	return status, head, nil
 }
 
+// dirtyFiles returns the sorted list of files which keep the worktree from being clean
+func dirtyFiles(status git.Status, allowUntracked bool) []string {
+	var dirty []string
+
+	for f, file := range status {
+		log.Debug().Str("file", f).
+			Int8("worktree_status", int8(file.Worktree)).
+			Int8("staging_status", int8(file.Staging)).
+			Msg("File status")
+
+		if allowUntracked && file.Worktree == git.Untracked {
+			continue
+		}
+
+		if file.Worktree != git.Unmodified || file.Staging != git.Unmodified {
+			dirty = append(dirty, f)
+		}
+	}
+
+	sort.Strings(dirty)
+
+	return dirty
+}
+
 func nextVersionFromChangeSet(changes *changes.ChangeSet, version semver.Version) semver.Version {
+	return bumpFromChangeSet(changes, version).Apply(version)
+}
+
+// bumpFromChangeSet determines how much the version must be incremented for the changes
+func bumpFromChangeSet(changes *changes.ChangeSet, version semver.Version) versions.Bump {
 	switch {
 	case len(changes.BreakingChanges) > 0:
 		log.Debug().Msg("We have breaking changes")
 		// We only increment major if we're post 1.0.  Before that all changes are a "minor" level
 		if version.Major() > 0 {
-			version = version.IncMajor()
+			return versions.BumpMajor
 		} else {
 			log.Debug().Msg("But we're before 1.0")
-			version = version.IncMinor()
+			return versions.BumpMinor
 		}
-	case len(changes.Commits["feat"]) > 0:
-		version = version.IncMinor()
+	case len(changes.Commits["feat"]) > 0 || len(changes.Additions) > 0:
+		return versions.BumpMinor
 	case len(changes.Commits["fix"]) > 0:
-		version = version.IncPatch()
+		return versions.BumpPatch
+	default:
+		return versions.BumpNone
 	}
-
-	return version
 }
 
 func (s *Semver) FindPreviousVersion(r *repo.Repository) (semver.Version, string, error) {
 	if s.FromFile != "" {
-		return versions.FindPreviousVersionFromFile(s.FromFile)
+		scheme, err := s.versionScheme(r)
+		if err != nil {
+			return semver.Version{}, "", err
+		}
+
+		v, err := versionfile.ReadVersion(s.FromFile, scheme)
+		switch {
+		case errors.Is(err, versionfile.ErrNotFound) && s.FromFileOrTag:
+			log.Debug().Str("file", s.FromFile).Msg("No version in file, examining tags")
+			return s.findPreviousVersionFromTag(r)
+		case errors.Is(err, versionfile.ErrNotFound):
+			log.Warn().Str("file", s.FromFile).Msg("No version found in file, starting from 0.0.0")
+			return semver.Version{}, "", nil
+		default:
+			return v, "", err
+		}
 	} else {
-		return versions.FindPreviousVersionFromTag(r)
+		return s.findPreviousVersionFromTag(r)
 	}
 }
 
-func (s *Semver) ReplaceInFile(filename string, new string) error {
-
-	// #nosec G304
-	f, err := os.Open(filename)
+// findPreviousVersionFromTag finds the most recent tag which is a version in the selected scheme
+func (s *Semver) findPreviousVersionFromTag(r *repo.Repository) (semver.Version, string, error) {
+	scheme, err := s.versionScheme(r)
 	if err != nil {
-		return err
+		return semver.Version{}, "", err
 	}
-	defer func() {
-		_ = f.Close()
-	}()
-
-	tmp := filename + ".tmp"
-	// #nosec G304
-	out, err := os.Create(tmp)
 
+	line, branch, err := s.maintenanceLine(r)
 	if err != nil {
-		return err
+		return semver.Version{}, "", err
+	}
+	if line == nil {
+		return versions.FindPreviousVersionFromTagScheme(r, "", scheme)
 	}
 
-	defer func() {
-		_ = out.Close()
-	}()
-
-	scanner := bufio.NewScanner(f)
+	version, foundTag, err := versions.FindPreviousVersionInLine(r, "", scheme, *line)
+	if err == nil && foundTag == "" {
+		err = fmt.Errorf("branch %s releases maintenance line %s, but none of its tags is a version of that line", branch, line)
+	}
+	return version, foundTag, err
+}
 
-	for scanner.Scan() {
-		line := versions.SemverRegexp.ReplaceAllString(scanner.Text(), new)
-		if _, err = out.WriteString(line); err != nil {
+// checkReplaceIn fails if the file of a --replace-in target does not exist, before anything is calculated or changed
+func checkReplaceIn(specs []string) error {
+	for _, spec := range specs {
+		target, err := versionfile.Parse(spec)
+		if err != nil {
 			return err
 		}
-		if _, err = out.WriteString("\n"); err != nil {
-			return err
+
+		stat, err := os.Stat(target.Path)
+		if err != nil {
+			return fmt.Errorf("--replace-in %s: %w", spec, err)
+		}
+		if stat.IsDir() {
+			return fmt.Errorf("--replace-in %s: %s is a directory", spec, target.Path)
 		}
 	}
+	return nil
+}
+
+// ReplaceInFiles replaces the version in the files given by the target specifications (see versionfile.Parse).
+// Either all files are updated or none are.  With --dry-run, the diff is written to out instead.
+func (s *Semver) ReplaceInFiles(out io.Writer, specs []string, new string) error {
+	if len(specs) == 0 {
+		return nil
+	}
 
-	if err = f.Close(); err != nil {
+	changes, err := versionfile.Prepare(specs, new)
+	if err != nil {
 		return err
 	}
 
-	if err = out.Close(); err != nil {
+	if s.DryRun {
+		diff, err := versionfile.Diff(changes)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(out, diff)
 		return err
 	}
 
-	return os.Rename(tmp, filename)
+	return versionfile.WriteAll(changes)
 }
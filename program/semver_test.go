@@ -1,9 +1,12 @@
 package program
 
 import (
+	"encoding/json"
 	"github.com/Masterminds/semver"
 	"github.com/deweysasser/changetool/changes"
 	"github.com/deweysasser/changetool/test_framework"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"os"
@@ -153,6 +156,11 @@ func TestSemverPostrelease(t *testing.T) {
 			"",
 			"1.3.0\n"))
 
+	t.Run("Docker format",
+		testSemver(r.Path,
+			"--version-format docker",
+			"1\n1.3\n1.3.0\nlatest\n"))
+
 	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat!: break the world"}, 0))
 
 	t.Run("With feat",
@@ -205,3 +213,762 @@ func testSemver(repo, additionalArgs, expected string) func(t *testing.T) {
 		assert.Equal(t, expected, string(bytes))
 	}
 }
+
+func TestSemverGoAPICheck(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{
+			Message: "feat: initial commit",
+			Contents: map[string]string{
+				"go.mod":     "module example.com/m\n\ngo 1.17\n",
+				"pkg/pkg.go": "package pkg\n\nfunc Keep() {}\n",
+			},
+		},
+		{Tag: "v1.0.0"},
+		{
+			Message:  "refactor: add to the API",
+			Contents: map[string]string{"pkg/pkg.go": "package pkg\n\nfunc Keep() {}\n\nfunc Added() {}\n"},
+		},
+	}))
+
+	t.Run("Addition", testSemver(r.Path, "--go-api-check", "1.1.0\n"))
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{
+			Message:  "refactor: remove from the API",
+			Contents: map[string]string{"pkg/pkg.go": "package pkg\n\nfunc Added() {}\n"},
+		},
+	}))
+
+	t.Run("Without check", testSemver(r.Path, "", "1.0.0\n"))
+	t.Run("Removal", testSemver(r.Path, "--go-api-check", "2.0.0\n"))
+
+	t.Run("Changelog", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "changelog", "--go-api-check")
+		assert.NoError(t, err)
+		assert.Equal(t, `Breaking Changes:
+   * example.com/m/pkg: Keep: removed
+
+Refactor:
+   * remove from the API
+   * add to the API
+
+`, out)
+	})
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{Message: "feat!: break the API in name only"},
+	}))
+
+	t.Run("Breaking commit", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "changelog")
+		assert.NoError(t, err)
+		assert.Equal(t, `Feature:
+   * break the API in name only
+
+Refactor:
+   * remove from the API
+   * add to the API
+
+`, out)
+	})
+}
+
+func TestSemverGoPseudo(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{Message: "feat: initial commit"},
+	}))
+
+	t.Run("No tag", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--go-pseudo")
+		assert.NoError(t, err)
+		assert.Regexp(t, `^v0\.0\.0-[0-9]{14}-[0-9a-f]{12}\n$`, out)
+	})
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{Tag: "v1.4.0"},
+	}))
+
+	t.Run("Tagged", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--go-pseudo")
+		assert.NoError(t, err)
+		assert.Equal(t, "v1.4.0\n", out)
+	})
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{Message: "fix: after the tag"},
+	}))
+
+	t.Run("Release tag", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--go-pseudo")
+		assert.NoError(t, err)
+		assert.Regexp(t, `^v1\.4\.1-0\.[0-9]{14}-[0-9a-f]{12}\n$`, out)
+	})
+
+	t.Run("Refuses to tag", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--go-pseudo", "--tag")
+		assert.Error(t, err)
+	})
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{Tag: "v1.5"},
+		{Message: "fix: after a short tag"},
+	}))
+
+	t.Run("Non-canonical tag", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--go-pseudo")
+		assert.NoError(t, err)
+		assert.Regexp(t, `^v1\.4\.1-0\.[0-9]{14}-[0-9a-f]{12}\n$`, out)
+	})
+}
+
+func TestSemverOutputFormat(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat: added a feat"}, 0))
+
+	head, err := r.Head()
+	must(t, err)
+	hash := head.Hash().String()
+
+	t.Run("json", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--output-format", "json")
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+  "previous_version": "1.2.0",
+  "previous_tag": "v1.2",
+  "next_version": "1.3.0",
+  "bump": "minor",
+  "release_needed": true,
+  "major": 1,
+  "minor": 3,
+  "patch": 0,
+  "prerelease": "",
+  "commit_count": 2,
+  "head": "`+hash+`"
+}`, out)
+	})
+
+	expected := `previous_version=1.2.0
+previous_tag=v1.2
+next_version=1.3.0
+bump=minor
+release_needed=true
+major=1
+minor=3
+patch=0
+prerelease=
+commit_count=2
+head=` + hash + "\n"
+
+	t.Run("env", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--output-format", "env")
+		assert.NoError(t, err)
+		assert.Equal(t, `PREVIOUS_VERSION=1.2.0
+PREVIOUS_TAG=v1.2
+NEXT_VERSION=1.3.0
+BUMP=minor
+RELEASE_NEEDED=true
+MAJOR=1
+MINOR=3
+PATCH=0
+PRERELEASE=
+COMMIT_COUNT=2
+HEAD=`+hash+"\n", out)
+	})
+
+	t.Run("github", func(t *testing.T) {
+		githubOutput := path.Join(test_framework.TestDir(t), "github_output")
+		must(t, os.WriteFile(githubOutput, []byte("existing=value\n"), 0600))
+		t.Setenv("GITHUB_OUTPUT", githubOutput)
+
+		out, err := runCommand(t, r.Path, "semver", "--output-format", "github")
+		assert.NoError(t, err)
+		assert.Equal(t, "", out)
+
+		bytes, err := os.ReadFile(githubOutput)
+		must(t, err)
+		assert.Equal(t, "existing=value\n"+expected, string(bytes))
+	})
+
+	t.Run("version format", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--output-format", "json", "--version-format", "pep440")
+		assert.Error(t, err)
+	})
+
+	must(t, r.Run([]test_framework.GitOperation{{Tag: "v1.3.0"}}))
+
+	t.Run("released", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--output-format", "env")
+		assert.NoError(t, err)
+		assert.Contains(t, out, "NEXT_VERSION=1.3.0\n")
+		assert.Contains(t, out, "RELEASE_NEEDED=false\n")
+	})
+
+	must(t, os.WriteFile(path.Join(r.Path, "untracked.txt"), []byte("work in progress\n"), 0600))
+
+	t.Run("dirty", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--output-format", "env")
+		assert.NoError(t, err)
+		assert.Contains(t, out, "NEXT_VERSION=1.4.0-dirty.")
+		assert.Contains(t, out, "RELEASE_NEEDED=true\n")
+	})
+}
+
+func TestSemverExplain(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat(api): added a feat"}, 0))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "fix: added a fix"}, 0))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "tweak something"}, 0))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "chore: nothing"}, 0))
+
+	head, err := r.Head()
+	must(t, err)
+	short := head.Hash().String()[:7]
+
+	t.Run("text", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--explain")
+		assert.NoError(t, err)
+		lines := strings.Split(out, "\n")
+		assert.Equal(t, "Previous version: 1.2.0 (from tag v1.2)", lines[0])
+		assert.Equal(t, "Commit range:     v1.2.."+short+" (5 commits)", lines[1])
+		assert.Equal(t, "Contributing changes:", lines[2])
+		assert.Regexp(t, `^   [0-9a-f]{7} patch fix \(guessed\): tweak something \[fix commit\]$`, lines[3])
+		assert.Regexp(t, `^   [0-9a-f]{7} patch fix: added a fix \[fix commit\]$`, lines[4])
+		assert.Regexp(t, `^   [0-9a-f]{7} minor feat: added a feat \[feat commit\]$`, lines[5])
+		assert.Equal(t, "Worktree:         clean", lines[6])
+		assert.Equal(t, "Decision:         minor bump, 1.2.0 -> 1.3.0", lines[7])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--explain", "--output-format", "json")
+		assert.NoError(t, err)
+
+		var e Explanation
+		must(t, json.Unmarshal([]byte(out), &e))
+		assert.Equal(t, "tag v1.2", e.PreviousSource)
+		assert.Len(t, e.Contributions, 3)
+		assert.True(t, e.Contributions[0].Guessed)
+		assert.Equal(t, "api", e.Contributions[2].Scope)
+		assert.Equal(t, "minor", e.Bump)
+		assert.Equal(t, "1.3.0", e.NextVersion)
+	})
+}
+
+func TestSemverCheck(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat: added a feat"}, 0))
+
+	tests := []struct {
+		name    string
+		version string
+		error   string
+	}{
+		{"matches", "1.3.0", ""},
+		{"release candidate", "1.3.0-rc.1", ""},
+		{"under-bump", "1.2.1", "version 1.2.1 under-bumps: it is a patch bump from 1.2.0, but the changes since v1.2 require a minor bump to 1.3.0"},
+		{"over-bump", "2.0.0", "version 2.0.0 over-bumps: it is a major bump from 1.2.0, but the changes since v1.2 only call for a minor bump to 1.3.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runCommand(t, r.Path, "semver", "--check", tt.version)
+			if tt.error == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.version+" matches the calculated version 1.3.0\n", out)
+			} else {
+				assert.EqualError(t, err, tt.error)
+			}
+		})
+	}
+
+	t.Run("file", func(t *testing.T) {
+		file := path.Join(test_framework.TestDir(t), "VERSION")
+		must(t, os.WriteFile(file, []byte("version = \"1.2.1\"\n"), 0600))
+
+		_, err := runCommand(t, r.Path, "semver", "--check-file", file)
+		assert.Error(t, err)
+	})
+}
+
+func TestSemverReplaceIn(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat: added a feat"}, 0))
+
+	// go ignores testdata directories, so version.go won't become part of the build
+	dir := path.Join(test_framework.TestDir(t), "testdata")
+	must(t, os.MkdirAll(dir, 0750))
+	packageJSON := path.Join(dir, "package.json")
+	versionGo := path.Join(dir, "version.go")
+
+	must(t, os.WriteFile(packageJSON, []byte(`{"version": "1.2.0", "dependencies": {"x": "1.2.0"}}`+"\n"), 0600))
+	must(t, os.WriteFile(versionGo, []byte("package main\n\n// Version is the version\nconst Version = \"1.2.0\"\n\nconst Other = \"1.2.0\"\n"), 0600))
+
+	out, err := runCommand(t, r.Path, "semver", "--dry-run", "--tag", "--replace-in", packageJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0\n--- "+packageJSON+"\n+++ "+packageJSON+`
+@@ -1 +1 @@
+-{"version": "1.2.0", "dependencies": {"x": "1.2.0"}}
++{"version": "1.3.0", "dependencies": {"x": "1.2.0"}}
+`, out)
+	_, err = r.Tag("v1.3.0")
+	assert.ErrorIs(t, err, git.ErrTagNotFound)
+
+	out, err = runCommand(t, r.Path, "semver", "--allow-untracked", "--replace-in", packageJSON, "--replace-in", versionGo+":Version")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0\n", out)
+
+	bytes, err := os.ReadFile(packageJSON)
+	must(t, err)
+	assert.Equal(t, `{"version": "1.3.0", "dependencies": {"x": "1.2.0"}}`+"\n", string(bytes))
+
+	bytes, err = os.ReadFile(versionGo)
+	must(t, err)
+	assert.Equal(t, "package main\n\n// Version is the version\nconst Version = \"1.3.0\"\n\nconst Other = \"1.2.0\"\n", string(bytes))
+
+	// commas separate selectors and rules, not files
+	out, err = runCommand(t, r.Path, "semver", "--allow-untracked", "--replace-in", packageJSON+":.version,.dependencies.x,first")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0\n", out)
+
+	bytes, err = os.ReadFile(packageJSON)
+	must(t, err)
+	assert.Equal(t, `{"version": "1.3.0", "dependencies": {"x": "1.2.0"}}`+"\n", string(bytes))
+
+	// the file must exist, whatever the selector
+	_, err = runCommand(t, r.Path, "semver", "--allow-untracked", "--replace-in", path.Join(dir, "missing.json")+":.version")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestSemverFromFile(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "fix: added a fix"}, 0))
+
+	dir := test_framework.TestDir(t)
+	packageJSON := path.Join(dir, "package.json")
+	empty := path.Join(dir, "empty.json")
+	must(t, os.WriteFile(packageJSON, []byte(`{"dependencies": {"x": "9.9.9"}, "version": "3.1.0"}`), 0600))
+	must(t, os.WriteFile(empty, []byte(`{"name": "thing"}`), 0600))
+
+	t.Run("structured", testSemver(r.Path, "--from-file "+packageJSON, "3.1.1\n"))
+	t.Run("selector", testSemver(r.Path, "--from-file "+packageJSON+":.dependencies.x", "9.9.10\n"))
+	t.Run("no version", testSemver(r.Path, "--from-file "+empty, "0.0.1\n"))
+	t.Run("fallback to tags", testSemver(r.Path, "--from-file "+empty+" --from-file-or-tag", "1.2.1\n"))
+
+	calver := path.Join(dir, "VERSION")
+	must(t, os.WriteFile(calver, []byte("2024.05.3\n"), 0600))
+
+	t.Run("scheme", testSemver(r.Path, "--scheme calver --calver-format YYYY.0M.MICRO --date 2024-05-20 --from-file "+calver, "2024.05.4\n"))
+
+	t.Run("not in the scheme", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--scheme", "calver", "--calver-format", "YYYY.0M.MICRO", "--date", "2024-05-20", "--from-file", packageJSON)
+		assert.Error(t, err)
+	})
+}
+
+func TestSemverTagMessage(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat: added a feat"}, 0))
+
+	tagMessage := func(t *testing.T, name string) string {
+		ref, err := r.Tag(name)
+		must(t, err)
+		tag, err := r.TagObject(ref.Hash())
+		must(t, err)
+		return tag.Message
+	}
+
+	t.Run("default", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag")
+		assert.NoError(t, err)
+		assert.Equal(t, "Tag version 1.3.0\n\nFeature:\n   * added a feat\n\nDocs:\n   * another non-conventional commit, this time of doc\n", tagMessage(t, "v1.3.0"))
+	})
+
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "fix: added a fix"}, 1))
+
+	t.Run("template", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag", "--tag-message", "Release {{.Tag}} (from {{.PreviousVersion}})\n\n{{.Notes}}")
+		assert.NoError(t, err)
+		assert.Equal(t, "Release v1.3.1 (from 1.3.0)\n\nFix:\n   * added a fix\n", tagMessage(t, "v1.3.1"))
+	})
+
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "fix: another fix"}, 2))
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag", "--tag-message", "{{.Version")
+		assert.Error(t, err)
+		_, err = r.Tag("v1.3.2")
+		assert.ErrorIs(t, err, git.ErrTagNotFound)
+	})
+
+	t.Run("lightweight", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag", "--lightweight")
+		assert.NoError(t, err)
+		ref, err := r.Tag("v1.3.2")
+		must(t, err)
+		head, err := r.Head()
+		must(t, err)
+		assert.Equal(t, head.Hash(), ref.Hash())
+	})
+}
+
+func TestSemverTagSafety(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+
+	t.Run("nothing to release", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag")
+		assert.EqualError(t, err, "no commits since v1.2 call for a release, so there is nothing to tag.  Use --force to tag v1.2.0 anyway")
+	})
+
+	t.Run("force", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag", "--force")
+		assert.NoError(t, err)
+		_, err = r.Tag("v1.2.0")
+		assert.NoError(t, err)
+	})
+
+	// someone tagged v1.2.1 on another branch
+	w, err := r.Worktree()
+	must(t, err)
+	master, err := r.Head()
+	must(t, err)
+	must(t, w.Checkout(&git.CheckoutOptions{Branch: "refs/heads/side", Create: true}))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "fix: a fix on a side branch"}, 0))
+	side, err := r.Head()
+	must(t, err)
+	_, err = r.CreateTag("v1.2.1", side.Hash(), nil)
+	must(t, err)
+	must(t, w.Checkout(&git.CheckoutOptions{Branch: master.Name()}))
+
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "fix: added a fix"}, 1))
+
+	t.Run("tag on another commit", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag")
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "tag v1.2.1 already exists on commit "+side.Hash().String()[:7])
+			assert.Contains(t, err.Error(), "git tag -d v1.2.1")
+		}
+	})
+
+	must(t, r.DeleteTag("v1.2.1"))
+
+	t.Run("tag", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag")
+		assert.NoError(t, err)
+		assert.Equal(t, "1.2.1\n", out)
+	})
+
+	t.Run("already tagged", func(t *testing.T) {
+		before, err := r.Tag("v1.2.1")
+		must(t, err)
+
+		out, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag")
+		assert.NoError(t, err)
+		assert.Equal(t, "1.2.1\n", out)
+
+		after, err := r.Tag("v1.2.1")
+		must(t, err)
+		assert.Equal(t, before.Hash(), after.Hash())
+	})
+}
+
+func TestSemverReleaseAs(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "fix: added a fix"}, 0))
+
+	t.Run("set version", testSemver(r.Path, "--set-version 1.5.0", "1.5.0\n"))
+
+	t.Run("set version not greater", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--set-version", "1.1.0")
+		assert.EqualError(t, err, "version 1.1.0 set by --set-version is not greater than the previous version 1.2.0")
+	})
+
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "chore: jump to 2.0\n\nRelease-As: 2.0.0\n"}, 1))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "fix: another fix"}, 2))
+
+	head, err := r.Head()
+	must(t, err)
+	commit, err := r.CommitObject(head.Hash())
+	must(t, err)
+	footer := commit.ParentHashes[0].String()[:7]
+
+	t.Run("footer", testSemver(r.Path, "", "2.0.0\n"))
+	t.Run("flag overrides footer", testSemver(r.Path, "--set-version 3.0.0", "3.0.0\n"))
+
+	t.Run("explain", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--explain")
+		assert.NoError(t, err)
+		assert.Contains(t, out, "Version set:      2.0.0 by Release-As footer in commit "+footer+", instead of the calculated version\n")
+		assert.Contains(t, out, "Decision:         major bump, 1.2.0 -> 2.0.0\n")
+
+		out, err = runCommand(t, r.Path, "semver", "--explain", "--output-format", "json")
+		assert.NoError(t, err)
+		var e Explanation
+		must(t, json.Unmarshal([]byte(out), &e))
+		assert.Equal(t, "Release-As footer in commit "+footer, e.SetBy)
+		assert.Equal(t, "2.0.0", e.NextVersion)
+	})
+
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "chore: oops\n\nRelease-As: 1.0.0\n"}, 3))
+
+	t.Run("footer not greater", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver")
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "is not greater than the previous version 1.2.0")
+		}
+	})
+}
+
+func TestSemverBump(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "fix: added a fix"}, 0))
+
+	t.Run("calculated", testSemver(r.Path, "", "1.2.1\n"))
+	t.Run("major", testSemver(r.Path, "--bump major", "2.0.0\n"))
+	t.Run("minor", testSemver(r.Path, "--bump minor", "1.3.0\n"))
+	t.Run("none", testSemver(r.Path, "--bump none", "1.2.0\n"))
+	t.Run("candidates", testSemver(r.Path, "--candidates", "major 2.0.0\nminor 1.3.0\npatch 1.2.1 (calculated)\nnone  1.2.0\n"))
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--bump", "huge")
+		assert.Error(t, err)
+	})
+
+	t.Run("explain", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--bump", "minor", "--explain")
+		assert.NoError(t, err)
+		assert.Contains(t, out, "Bump set:         minor by --bump, instead of the patch the changes call for\n")
+		assert.Contains(t, out, "Decision:         minor bump, 1.2.0 -> 1.3.0\n")
+	})
+
+	t.Run("candidates json", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--candidates", "--output-format", "json")
+		assert.NoError(t, err)
+
+		var list []Candidate
+		must(t, json.Unmarshal([]byte(out), &list))
+		assert.Equal(t, []Candidate{
+			{Bump: "major", Version: "2.0.0"},
+			{Bump: "minor", Version: "1.3.0"},
+			{Bump: "patch", Version: "1.2.1", Calculated: true},
+			{Bump: "none", Version: "1.2.0"},
+		}, list)
+	})
+
+	t.Run("candidates env", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--candidates", "--output-format", "env")
+		assert.EqualError(t, err, "--candidates can't be used with --output-format env, only with text or json")
+	})
+
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "chore: jump\n\nRelease-As: 3.0.0\n"}, 1))
+
+	t.Run("overrides footer", testSemver(r.Path, "--bump patch", "1.2.1\n"))
+
+	t.Run("with set version", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--bump", "patch", "--set-version", "4.0.0")
+		assert.EqualError(t, err, "--set-version and --bump can't be used together")
+	})
+}
+
+func TestSemverCalVer(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.Run([]test_framework.GitOperation{
+		{Message: "feat: initial commit"},
+		{Tag: "v2024.05.0"},
+		{Message: "fix: added a fix"},
+	}))
+
+	t.Run("same month", testSemver(r.Path, "--scheme calver --calver-format YYYY.0M.MICRO --date 2024-05-20", "2024.05.1\n"))
+	t.Run("new month", testSemver(r.Path, "--scheme calver --calver-format YYYY.0M.MICRO --date 2024-06-02", "2024.06.0\n"))
+	t.Run("candidates", testSemver(r.Path, "--scheme calver --calver-format YYYY.0M.MICRO --date 2024-05-20 --candidates",
+		"major 2024.05.1\nminor 2024.05.1\npatch 2024.05.1 (calculated)\nnone  2024.05.0\n"))
+
+	t.Run("version format", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--scheme", "calver", "--date", "2024-05-20", "--version-format", "pep440")
+		assert.EqualError(t, err, "--version-format pep440 needs the semver scheme, not calver")
+	})
+
+	t.Run("git config", func(t *testing.T) {
+		cfg, err := r.Config()
+		must(t, err)
+		cfg.Raw.Section("changetool").SetOption("scheme", "calver").SetOption("calverFormat", "YYYY.0M.MICRO")
+		must(t, r.SetConfig(cfg))
+
+		_, err = runCommand(t, r.Path, "semver", "--allow-untracked", "--date", "2024-05-20", "--tag")
+		assert.NoError(t, err)
+
+		head, err := r.Head()
+		must(t, err)
+		tag, err := r.Tag("v2024.05.1")
+		if assert.NoError(t, err) {
+			object, err := r.TagObject(tag.Hash())
+			must(t, err)
+			assert.Equal(t, head.Hash(), object.Target)
+		}
+	})
+}
+
+func TestSemverMaintenance(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.Run([]test_framework.GitOperation{
+		{Message: "feat: added a feat"},
+		{Tag: "v1.3.0"},
+	}))
+
+	w, err := r.Worktree()
+	must(t, err)
+	must(t, w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("release/1.2"), Create: true}))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat: backported a feat"}, 0))
+
+	t.Run("capped", testSemver(r.Path, "--allow-untracked", "1.2.1\n"))
+	t.Run("not maintenance", testSemver(r.Path, "--allow-untracked --branch main", "1.4.0\n"))
+	t.Run("explicit line", testSemver(r.Path, "--allow-untracked --maintenance-branch release/*=1.x", "1.4.0\n"))
+
+	t.Run("disabled", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--maintenance-branch", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "1.4.0\n", out)
+	})
+
+	t.Run("explain", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--explain")
+		assert.NoError(t, err)
+		assert.Contains(t, out, "Previous version: 1.2.0 (from tag v1.2)\n")
+		assert.Contains(t, out, "Maintenance line: 1.2.x (branch release/1.2)\n")
+		assert.Contains(t, out, "Bump capped:      minor to patch by maintenance line 1.2.x\n")
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--maintenance-policy", "fail")
+		assert.EqualError(t, err, "the minor bump the changes since v1.2 call for exceeds maintenance line 1.2.x of branch release/1.2, which allows at most a patch bump")
+	})
+
+	t.Run("explicit bump", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--bump", "major")
+		assert.EqualError(t, err, "the major bump set by --bump exceeds maintenance line 1.2.x of branch release/1.2, which allows at most a patch bump")
+	})
+
+	t.Run("set version outside line", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--set-version", "1.5.0")
+		assert.EqualError(t, err, "version 1.5.0 is outside maintenance line 1.2.x of branch release/1.2")
+	})
+
+	t.Run("no tag in line", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--branch", "release/1.7")
+		assert.EqualError(t, err, "branch release/1.7 releases maintenance line 1.7.x, but none of its tags is a version of that line")
+	})
+
+	t.Run("dirty", func(t *testing.T) {
+		must(t, os.WriteFile(path.Join(r.Path, "untracked.txt"), []byte("work in progress\n"), 0600))
+		defer func() { must(t, os.Remove(path.Join(r.Path, "untracked.txt"))) }()
+
+		out, err := runCommand(t, r.Path, "semver", "--explain")
+		assert.NoError(t, err)
+		assert.Contains(t, out, "Worktree:         dirty, making a patch bump and dirty prerelease (untracked.txt)\n")
+		assert.Regexp(t, `Decision:         patch bump, 1.2.0 -> 1.2.2-dirty\.[0-9a-f]{6}\n`, out)
+	})
+}
+
+func TestSemverGuard(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "fix: added a fix"}, 0))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat!: removed the old API"}, 1))
+
+	head, err := r.Head()
+	must(t, err)
+	breaking := head.Hash().String()[:7]
+
+	t.Run("unguarded", testSemver(r.Path, "--allow-untracked", "2.0.0\n"))
+	t.Run("allowed", testSemver(r.Path, "--allow-untracked --max-version 1.x --allow-major", "2.0.0\n"))
+	t.Run("within", testSemver(r.Path, "--allow-untracked --max-version 2.x", "2.0.0\n"))
+
+	t.Run("max version", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--max-version", "1.x")
+		assert.EqualError(t, err, "version 2.0.0 is beyond the maximum version 1.x, because of these changes:\n"+
+			"   "+breaking+" feat: removed the old API [breaking change]\n"+
+			"Use --allow-major if the major release is intended")
+	})
+
+	t.Run("max bump", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--max-bump", "minor")
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "the major bump the changes since v1.2 call for is bigger than the maximum minor bump")
+		}
+	})
+
+	t.Run("set version", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--max-version", "1.x", "--set-version", "3.0.0")
+		assert.EqualError(t, err, "version 3.0.0 is beyond the maximum version 1.x (set by --set-version)\n"+
+			"Use --allow-major if the major release is intended")
+	})
+
+	t.Run("git config", func(t *testing.T) {
+		cfg, err := r.Config()
+		must(t, err)
+		cfg.Raw.Section("changetool").SetOption("maxVersion", "1.x")
+		must(t, r.SetConfig(cfg))
+
+		_, err = runCommand(t, r.Path, "semver", "--allow-untracked")
+		assert.Error(t, err)
+
+		out, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--allow-major")
+		assert.NoError(t, err)
+		assert.Equal(t, "2.0.0\n", out)
+	})
+
+	// the guard applies to versions which are used, not to the reports
+	t.Run("explain", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--explain")
+		assert.NoError(t, err)
+		assert.Contains(t, out, "Guard:            refused, because version 2.0.0 is beyond the maximum version 1.x\n")
+
+		_, err = runCommand(t, r.Path, "semver", "--allow-untracked", "--explain", "--tag")
+		assert.Error(t, err)
+		_, err = r.Tag("v2.0.0")
+		assert.Error(t, err)
+	})
+
+	t.Run("candidates", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--candidates")
+		assert.NoError(t, err)
+		assert.Contains(t, out, "2.0.0")
+	})
+}
@@ -0,0 +1,132 @@
+package program
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/go-git/go-git/v5/plumbing"
+	"os"
+	"strings"
+	"time"
+)
+
+// PassphraseEnv is the environment variable holding the passphrase of the signing key
+const PassphraseEnv = "CHANGETOOL_SIGN_PASSPHRASE"
+
+// readKeyRing reads an OpenPGP key ring file, either armored or binary
+func readKeyRing(path string) (openpgp.EntityList, error) {
+	// #nosec G304
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Contains(content, []byte("-----BEGIN PGP")) {
+		return openpgp.ReadArmoredKeyRing(bytes.NewReader(content))
+	}
+
+	return openpgp.ReadKeyRing(bytes.NewReader(content))
+}
+
+// signingKey returns the first private key of the key ring file, with the key which signs decrypted with the
+// passphrase from the environment or the passphrase file
+func (t *Tagging) signingKey() (*openpgp.Entity, error) {
+	keys, err := readKeyRing(t.SignKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signing key %s: %w", t.SignKey, err)
+	}
+
+	var entity *openpgp.Entity
+	for _, e := range keys {
+		if e.PrivateKey != nil {
+			entity = e
+			break
+		}
+	}
+
+	if entity == nil {
+		return nil, fmt.Errorf("%s has no private key", t.SignKey)
+	}
+
+	// the primary key or a subkey, as chosen when signing
+	key, ok := entity.SigningKey(time.Now())
+	if !ok || key.PrivateKey == nil {
+		return nil, fmt.Errorf("%s has no private key which can sign", t.SignKey)
+	}
+
+	if key.PrivateKey.Encrypted {
+		passphrase, err := t.passphrase()
+		if err != nil {
+			return nil, err
+		}
+		if passphrase == nil {
+			return nil, fmt.Errorf("the key in %s needs a passphrase:  set %s or use --sign-passphrase-file", t.SignKey, PassphraseEnv)
+		}
+		if err = key.PrivateKey.Decrypt(passphrase); err != nil {
+			return nil, fmt.Errorf("unable to decrypt the key in %s: %w", t.SignKey, err)
+		}
+	}
+
+	return entity, nil
+}
+
+// passphrase returns the passphrase of the signing key, or nil if none was given
+func (t *Tagging) passphrase() ([]byte, error) {
+	if t.SignPassphraseFile != "" {
+		// #nosec G304
+		content, err := os.ReadFile(t.SignPassphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.TrimRight(string(content), "\r\n")), nil
+	}
+
+	if p, found := os.LookupEnv(PassphraseEnv); found {
+		return []byte(p), nil
+	}
+
+	return nil, nil
+}
+
+// verifyTag checks that the tag is an annotated tag signed by a key in the key ring file
+func verifyTag(r *repo.Repository, name, keyRingFile string) error {
+	keys, err := readKeyRing(keyRingFile)
+	if err != nil {
+		return fmt.Errorf("unable to read key ring %s: %w", keyRingFile, err)
+	}
+
+	ref, err := r.Tag(name)
+	if err != nil {
+		return err
+	}
+
+	tag, err := r.TagObject(ref.Hash())
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return fmt.Errorf("tag %s is not signed:  it is a lightweight tag", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if tag.PGPSignature == "" {
+		return fmt.Errorf("tag %s is not signed", name)
+	}
+
+	encoded := &plumbing.MemoryObject{}
+	if err = tag.EncodeWithoutSignature(encoded); err != nil {
+		return err
+	}
+
+	reader, err := encoded.Reader()
+	if err != nil {
+		return err
+	}
+
+	if _, err = openpgp.CheckArmoredDetachedSignature(keys, reader, strings.NewReader(tag.PGPSignature), nil); err != nil {
+		return fmt.Errorf("tag %s has an invalid signature: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package program
+
+import (
+	"bytes"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/deweysasser/changetool/test_framework"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"testing"
+)
+
+// writeTestKey generates a throwaway key, writing the private key (encrypted with the passphrase) and the public key to
+// armored key ring files in the directory
+func writeTestKey(t *testing.T, dir, name, passphrase string) (private, public string) {
+	entity, err := openpgp.NewEntity(name, "testing", name+"@example.com", nil)
+	must(t, err)
+
+	var pub bytes.Buffer
+	w, err := armor.Encode(&pub, openpgp.PublicKeyType, nil)
+	must(t, err)
+	must(t, entity.Serialize(w))
+	must(t, w.Close())
+
+	must(t, entity.PrivateKey.Encrypt([]byte(passphrase)))
+	for _, sub := range entity.Subkeys {
+		must(t, sub.PrivateKey.Encrypt([]byte(passphrase)))
+	}
+
+	var priv bytes.Buffer
+	w, err = armor.Encode(&priv, openpgp.PrivateKeyType, nil)
+	must(t, err)
+	must(t, entity.SerializePrivateWithoutSigning(w, nil))
+	must(t, w.Close())
+
+	private, public = path.Join(dir, name+".key"), path.Join(dir, name+".pub")
+	must(t, os.WriteFile(private, priv.Bytes(), 0600))
+	must(t, os.WriteFile(public, pub.Bytes(), 0600))
+
+	return private, public
+}
+
+func TestSignedTags(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("../versions/release-repo.yaml"))
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "feat: added a feat"}, 0))
+
+	dir := test_framework.TestDir(t)
+	private, public := writeTestKey(t, dir, "release", "secret")
+	_, otherPublic := writeTestKey(t, dir, "other", "other")
+
+	passphraseFile := path.Join(dir, "passphrase")
+	must(t, os.WriteFile(passphraseFile, []byte("secret\n"), 0600))
+
+	rr, err := repo.New(r.Path)
+	must(t, err)
+
+	t.Run("previous tag unsigned", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--verify-tags", public)
+		assert.EqualError(t, err, "tag v1.2 is not signed")
+	})
+
+	t.Run("no passphrase", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag", "--sign-key", private)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "needs a passphrase")
+		}
+		_, err = r.Tag("v1.3.0")
+		assert.Error(t, err)
+	})
+
+	t.Run("only the signing key is decrypted", func(t *testing.T) {
+		tagging := Tagging{SignKey: private, SignPassphraseFile: passphraseFile}
+		entity, err := tagging.signingKey()
+		must(t, err)
+
+		assert.False(t, entity.PrivateKey.Encrypted)
+		for _, sub := range entity.Subkeys {
+			assert.True(t, sub.PrivateKey.Encrypted, "the encryption subkey stays encrypted")
+		}
+	})
+
+	t.Run("lightweight", func(t *testing.T) {
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag", "--lightweight", "--sign-key", private)
+		assert.EqualError(t, err, "lightweight tags cannot be signed")
+	})
+
+	t.Run("sign with passphrase file", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag", "--sign-key", private, "--sign-passphrase-file", passphraseFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "1.3.0\n", out)
+
+		assert.NoError(t, verifyTag(rr, "v1.3.0", public))
+		assert.Error(t, verifyTag(rr, "v1.3.0", otherPublic))
+	})
+
+	must(t, r.RunCommit(test_framework.GitOperation{Message: "fix: added a fix"}, 1))
+
+	t.Run("verify previous tag", func(t *testing.T) {
+		out, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--verify-tags", public)
+		assert.NoError(t, err)
+		assert.Equal(t, "1.3.1\n", out)
+
+		_, err = runCommand(t, r.Path, "semver", "--allow-untracked", "--verify-tags", otherPublic)
+		assert.Error(t, err)
+	})
+
+	t.Run("sign with passphrase from environment", func(t *testing.T) {
+		t.Setenv(PassphraseEnv, "secret")
+		_, err := runCommand(t, r.Path, "semver", "--allow-untracked", "--tag", "--sign-key", private, "--verify-tags", public)
+		assert.NoError(t, err)
+		assert.NoError(t, verifyTag(rr, "v1.3.1", public))
+	})
+}
@@ -0,0 +1,124 @@
+package program
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/rs/zerolog/log"
+	"strings"
+	"text/template"
+)
+
+// DefaultTagMessage is the template of the message of annotated version tags
+const DefaultTagMessage = "Tag version {{.Version}}\n\n{{.Notes}}"
+
+// Tagging holds the options for creating version tags
+type Tagging struct {
+	TagMessage         string `group:"tagging" placeholder:"TEMPLATE" help:"text/template for the message of annotated tags, given .Version, .Tag, .PreviousVersion and .Notes (the release notes).  By default, 'Tag version {{.Version}}' followed by the notes"`
+	Lightweight        bool   `group:"tagging" help:"create a lightweight tag, which has no message, instead of an annotated tag"`
+	SignKey            string `group:"tagging" placeholder:"KEYRING" help:"sign tags with the first private key in this OpenPGP key ring file.  The passphrase is read from $CHANGETOOL_SIGN_PASSPHRASE or --sign-passphrase-file"`
+	SignPassphraseFile string `group:"tagging" placeholder:"FILE" help:"read the passphrase of the --sign-key from this file"`
+	Push               bool   `group:"tagging" help:"push the new tag (and the release commit) to --remote"`
+	Remote             string `group:"tagging" default:"origin" help:"the remote to which --push pushes"`
+
+	signer *openpgp.Entity
+}
+
+// tagMessageData is given to the tag message template
+type tagMessageData struct {
+	Version         string
+	Tag             string
+	PreviousVersion string
+	Notes           string
+}
+
+// tagName returns the name of the tag for a version
+func tagName(version string) string {
+	return "v" + version
+}
+
+// validate checks the options before anything is changed
+func (t *Tagging) validate() error {
+	if t.TagMessage != "" {
+		if _, err := template.New("tag message").Parse(t.TagMessage); err != nil {
+			return fmt.Errorf("invalid --tag-message: %w", err)
+		}
+	}
+
+	if t.SignKey != "" {
+		if t.Lightweight {
+			return errors.New("lightweight tags cannot be signed")
+		}
+
+		signer, err := t.signingKey()
+		if err != nil {
+			return err
+		}
+		t.signer = signer
+	}
+
+	return nil
+}
+
+// existingTag returns true if the tag already exists on the commit, and an error if it exists on another commit
+func existingTag(r *repo.Repository, name string, commit plumbing.Hash) (bool, error) {
+	target, exists := r.TagMap()[name]
+	switch {
+	case !exists:
+		return false, nil
+	case target == commit:
+		return true, nil
+	default:
+		return false, fmt.Errorf("tag %s already exists on commit %s, not on %s.  If that commit is the release, "+
+			"there is nothing to do; if the tag is wrong, delete it with 'git tag -d %s'",
+			name, target.String()[:7], commit.String()[:7], name)
+	}
+}
+
+// message renders the message of the tag for the calculation
+func (t *Tagging) message(calc *Calculation, notes string) (string, error) {
+	text := t.TagMessage
+	if text == "" {
+		text = DefaultTagMessage
+	}
+
+	tmpl, err := template.New("tag message").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, tagMessageData{
+		Version:         calc.next(),
+		Tag:             calc.tag(),
+		PreviousVersion: calc.previous(),
+		Notes:           strings.TrimRight(notes, "\n"),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n", nil
+}
+
+// createTag creates the tag for the calculated version on the commit, with the release notes in its message
+func (t *Tagging) createTag(r *repo.Repository, calc *Calculation, commit plumbing.Hash, notes string) error {
+	name := calc.tag()
+
+	if t.Lightweight {
+		log.Debug().Str("tag", name).Msg("Creating lightweight tag")
+		_, err := r.CreateTag(name, commit, nil)
+		return err
+	}
+
+	message, err := t.message(calc, notes)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.CreateTag(name, commit, &git.CreateTagOptions{Message: message, SignKey: t.signer})
+	return err
+}
@@ -1,20 +1,41 @@
 package repo
 
 import (
+	"errors"
+	"fmt"
 	"github.com/deweysasser/changetool/perf"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/rs/zerolog/log"
+	"sort"
 	"sync"
+	"time"
 )
 
 type Repository struct {
 	*git.Repository
+	tags             map[string]Tag
 	tagToCommitHash  map[string]plumbing.Hash
 	commitHashToTags map[plumbing.Hash][]string
 	filled           sync.Once
 }
 
+// Tag describes a tag of a commit
+type Tag struct {
+	Name string
+	// Commit is the commit tagged, found by following any chain of tag objects
+	Commit plumbing.Hash
+	// Annotated is true if the tag is a tag object rather than a lightweight tag
+	Annotated bool
+	// Tagger, Message and Signed come from the tag object the reference points to, and are empty for lightweight tags
+	Tagger  object.Signature
+	Message string
+	Signed  bool
+	// Date is when the tag was made, or for lightweight tags when the commit was committed
+	Date time.Time
+}
+
 func New(path string) (*Repository, error) {
 	if r, err := git.PlainOpen(path); err != nil {
 		return nil, err
@@ -39,9 +60,74 @@ func (r *Repository) ReverseTagMap() map[plumbing.Hash][]string {
 	return r.commitHashToTags
 }
 
+// TagInfo returns the tag with the name, and false if there is no such tag of a commit
+func (r *Repository) TagInfo(name string) (Tag, bool) {
+	r.filled.Do(r.fillTags)
+
+	tag, found := r.tags[name]
+	return tag, found
+}
+
+// AllTags returns the tags of commits, ordered by name
+func (r *Repository) AllTags() []Tag {
+	r.filled.Do(r.fillTags)
+
+	tags := make([]Tag, 0, len(r.tags))
+	for _, tag := range r.tags {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	return tags
+}
+
+// resolveTag describes the tag the reference points to, following chains of tag objects to the commit.  It fails for
+// tags of trees and blobs.
+func (r *Repository) resolveTag(name string, hash plumbing.Hash) (Tag, error) {
+	tag := Tag{Name: name}
+
+	for {
+		tagObject, err := r.TagObject(hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			break
+		}
+		if err != nil {
+			return tag, err
+		}
+
+		if !tag.Annotated {
+			tag.Annotated = true
+			tag.Tagger = tagObject.Tagger
+			tag.Message = tagObject.Message
+			tag.Signed = tagObject.PGPSignature != ""
+			tag.Date = tagObject.Tagger.When
+		}
+
+		switch tagObject.TargetType {
+		case plumbing.TagObject, plumbing.CommitObject:
+			hash = tagObject.Target
+		default:
+			return tag, fmt.Errorf("tag points to a %s, not a commit", tagObject.TargetType)
+		}
+	}
+
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		return tag, fmt.Errorf("tag does not point to a commit: %w", err)
+	}
+
+	tag.Commit = commit.Hash
+	if !tag.Annotated {
+		tag.Date = commit.Committer.When
+	}
+
+	return tag, nil
+}
+
 func (r *Repository) fillTags() {
 	defer perf.Timer("filling tagToCommitHash map").Stop()
 
+	r.tags = make(map[string]Tag)
 	r.tagToCommitHash = make(map[string]plumbing.Hash)
 	r.commitHashToTags = make(map[plumbing.Hash][]string)
 
@@ -52,20 +138,22 @@ func (r *Repository) fillTags() {
 
 		if err := tagRefs.ForEach(func(t *plumbing.Reference) error {
 			name := t.Name().Short()
-			hash := t.Hash()
 
-			// If it's a tag object, resolve it to the commit it's pointing to
-			if tag, err := r.TagObject(hash); err == nil {
-				hash = tag.Target
+			tag, err := r.resolveTag(name, t.Hash())
+			if err != nil {
+				log.Warn().Err(err).Str("tag", name).Msg("Ignoring tag")
+				return nil
 			}
 
 			log.Debug().
 				Str("tag", name).
-				Str("hash", hash.String()[:6]).
+				Str("hash", tag.Commit.String()[:6]).
+				Bool("annotated", tag.Annotated).
 				Msg("Examining tag")
 
-			r.tagToCommitHash[name] = hash
-			r.commitHashToTags[hash] = append(r.commitHashToTags[hash], name)
+			r.tags[name] = tag
+			r.tagToCommitHash[name] = tag.Commit
+			r.commitHashToTags[tag.Commit] = append(r.commitHashToTags[tag.Commit], name)
 
 			return nil
 		}); err != nil {
@@ -2,8 +2,11 @@ package repo
 
 import (
 	"github.com/deweysasser/changetool/test_framework"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestTags(t *testing.T) {
@@ -45,3 +48,78 @@ func must(t *testing.T, err error) {
 		t.Fatal(err)
 	}
 }
+
+func TestTagInfo(t *testing.T) {
+	r, err := test_framework.NewFromTest(t)
+	must(t, err)
+
+	must(t, r.RunFile("release-repo.yaml"))
+
+	head, err := r.Head()
+	must(t, err)
+	commit, err := r.CommitObject(head.Hash())
+	must(t, err)
+
+	tagger := &object.Signature{Name: "Tagger", Email: "tagger@example.com", When: time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)}
+	annotated, err := r.CreateTag("v2.0", head.Hash(), &git.CreateTagOptions{Tagger: tagger, Message: "Release v2.0\n"})
+	must(t, err)
+	_, err = r.CreateTag("v2.0-chain", annotated.Hash(), &git.CreateTagOptions{Tagger: tagger, Message: "Tag of a tag\n"})
+	must(t, err)
+	_, err = r.CreateTag("v2.0-tree", commit.TreeHash, nil)
+	must(t, err)
+	_, err = r.CreateTag("v2.0-annotated-tree", commit.TreeHash, &git.CreateTagOptions{Tagger: tagger, Message: "Tag of a tree\n"})
+	must(t, err)
+
+	repo, _ := FromRepository(r.Repository, nil)
+
+	t.Run("lightweight", func(t *testing.T) {
+		tag, found := repo.TagInfo("v1.1")
+		if assert.True(t, found) {
+			assert.False(t, tag.Annotated)
+			assert.Equal(t, "", tag.Message)
+			assert.False(t, tag.Signed)
+
+			c, err := r.CommitObject(tag.Commit)
+			must(t, err)
+			assert.Equal(t, c.Committer.When, tag.Date)
+		}
+	})
+
+	t.Run("annotated", func(t *testing.T) {
+		tag, found := repo.TagInfo("v2.0")
+		if assert.True(t, found) {
+			assert.True(t, tag.Annotated)
+			assert.Equal(t, head.Hash(), tag.Commit)
+			assert.Equal(t, "Release v2.0\n", tag.Message)
+			assert.Equal(t, "Tagger", tag.Tagger.Name)
+			assert.True(t, tagger.When.Equal(tag.Date))
+			assert.False(t, tag.Signed)
+		}
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		tag, found := repo.TagInfo("v2.0-chain")
+		if assert.True(t, found) {
+			assert.Equal(t, head.Hash(), tag.Commit)
+			assert.Equal(t, "Tag of a tag\n", tag.Message)
+		}
+		assert.Contains(t, repo.ReverseTagMap()[head.Hash()], "v2.0-chain")
+	})
+
+	t.Run("not commits", func(t *testing.T) {
+		_, found := repo.TagInfo("v2.0-tree")
+		assert.False(t, found)
+		_, found = repo.TagInfo("v2.0-annotated-tree")
+		assert.False(t, found)
+		_, found = repo.TagMap()["v2.0-tree"]
+		assert.False(t, found)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		var names []string
+		for _, tag := range repo.AllTags() {
+			names = append(names, tag.Name)
+		}
+		assert.Equal(t, []string{"v1.1", "v1.2", "v2.0", "v2.0-chain"}, names)
+	})
+}
@@ -16,6 +16,8 @@ type GitOperation struct {
 	Message string   `yaml:"message"`
 	Files   []string `yaml:"files"`
 	Tag     string   `yaml:"tag"`
+	// Contents sets the full content of the given files instead of appending a line to them
+	Contents map[string]string `yaml:"contents"`
 }
 
 type MyRepo struct {
@@ -90,7 +92,7 @@ func (r MyRepo) RunTag(op GitOperation) error {
 
 func (r MyRepo) RunCommit(op GitOperation, n int) error {
 
-	if len(op.Files) == 0 {
+	if len(op.Files) == 0 && len(op.Contents) == 0 {
 		op.Files = append(op.Files, "example-file.c")
 	}
 
@@ -99,8 +101,25 @@ func (r MyRepo) RunCommit(op GitOperation, n int) error {
 		return err
 	}
 
+	for file, content := range op.Contents {
+		filePath := path.Join(r.Path, file)
+		if err := os.MkdirAll(path.Dir(filePath), os.ModeDir|os.ModePerm); err != nil {
+			return err
+		}
+		// #nosec G306
+		if err := os.WriteFile(filePath, []byte(content), os.ModePerm); err != nil {
+			return fmt.Errorf("error writing to file %s: %w", file, err)
+		}
+		if _, err = w.Add(file); err != nil {
+			return fmt.Errorf("error adding file %s in %s: %w", file, r.Path, err)
+		}
+	}
+
 	for _, file := range op.Files {
 		filePath := path.Join(r.Path, file)
+		if err := os.MkdirAll(path.Dir(filePath), os.ModeDir|os.ModePerm); err != nil {
+			return err
+		}
 		// #nosec G304
 		fp, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, os.ModePerm)
 		if err != nil {
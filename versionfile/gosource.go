@@ -0,0 +1,50 @@
+package versionfile
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// goFormat finds the string value of a named const or var in go source, e.g. "Version"
+type goFormat struct{}
+
+func (goFormat) locate(content []byte, selector string) ([]span, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || (gen.Tok != token.CONST && gen.Tok != token.VAR) {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			for n, name := range value.Names {
+				if name.Name != selector {
+					continue
+				}
+
+				if n >= len(value.Values) {
+					return nil, fmt.Errorf("%s has no value", selector)
+				}
+
+				lit, ok := value.Values[n].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return nil, fmt.Errorf("%s is not a string literal", selector)
+				}
+
+				start := fset.Position(lit.Pos()).Offset
+				end := fset.Position(lit.End()).Offset
+				return []span{{start + 1, end - 1}}, nil
+			}
+		}
+	}
+
+	return nil, ErrNotFound
+}
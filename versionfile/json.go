@@ -0,0 +1,96 @@
+package versionfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// jsonFormat finds string values by dotted key path, e.g. ".version".  Array elements are selected by index.
+type jsonFormat struct{}
+
+type jsonFrame struct {
+	object    bool
+	expectKey bool
+	key       string
+	index     int
+}
+
+func (jsonFormat) locate(content []byte, selector string) ([]span, error) {
+	target := strings.Join(splitPath(selector), ".")
+
+	dec := json.NewDecoder(bytes.NewReader(content))
+	var stack []*jsonFrame
+
+	path := func() string {
+		keys := make([]string, len(stack))
+		for n, f := range stack {
+			if f.object {
+				keys[n] = f.key
+			} else {
+				keys[n] = strconv.Itoa(f.index)
+			}
+		}
+		return strings.Join(keys, ".")
+	}
+
+	valueDone := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			top.expectKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	for {
+		before := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			valueDone()
+			continue
+		}
+
+		if len(stack) > 0 && stack[len(stack)-1].object && stack[len(stack)-1].expectKey {
+			stack[len(stack)-1].key = tok.(string)
+			stack[len(stack)-1].expectKey = false
+			continue
+		}
+
+		atTarget := len(stack) > 0 && path() == target
+
+		switch v := tok.(type) {
+		case json.Delim:
+			if atTarget {
+				return nil, fmt.Errorf("value is not a string")
+			}
+			stack = append(stack, &jsonFrame{object: v == '{', expectKey: v == '{'})
+			continue
+		case string:
+			if atTarget {
+				start := before + bytes.IndexByte(content[before:], '"')
+				return []span{{start + 1, int(dec.InputOffset()) - 1}}, nil
+			}
+		default:
+			if atTarget {
+				return nil, fmt.Errorf("value is not a string")
+			}
+		}
+
+		valueDone()
+	}
+}
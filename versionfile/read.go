@@ -0,0 +1,50 @@
+package versionfile
+
+import (
+	"fmt"
+	"github.com/Masterminds/semver"
+	"github.com/deweysasser/changetool/versions"
+	"os"
+)
+
+// Read returns the first version value found in the content
+func (t Target) Read(content []byte) (string, error) {
+	spans, err := t.locate(content)
+	if err != nil {
+		return "", err
+	}
+
+	spans = t.Rules.filter(content, spans)
+	if len(spans) == 0 {
+		return "", fmt.Errorf("%s: %w", t.Path, ErrNotFound)
+	}
+
+	return string(content[spans[0].start:spans[0].end]), nil
+}
+
+// ReadVersion reads the version from the file given by the target specification (see Parse), parsed in the scheme.  It
+// returns ErrNotFound if the file holds no version.
+func ReadVersion(spec string, scheme versions.Scheme) (semver.Version, error) {
+	target, err := Parse(spec)
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	// #nosec G304
+	content, err := os.ReadFile(target.Path)
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	s, err := target.Read(content)
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	v, err := scheme.Parse(s)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("%s: invalid version %q: %w", target.Path, s, err)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,62 @@
+package versionfile
+
+import (
+	"github.com/deweysasser/changetool/test_framework"
+	"github.com/deweysasser/changetool/versions"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadVersion(t *testing.T) {
+	dir := filepath.Join(test_framework.TestDir(t), "testdata")
+	must(t, os.MkdirAll(dir, 0750))
+
+	files := map[string]string{
+		"package.json":   `{"dependencies": {"left-pad": "9.9.9"}, "version": "1.2.0"}`,
+		"Chart.yaml":     "dependencies:\n  - version: 9.9.9\nversion: 1.2.0\n",
+		"Cargo.toml":     "[dependencies]\nserde = \"9.9.9\"\n\n[package]\nversion = \"1.2.0\"\n",
+		"pom.xml":        "<project><parent><version>9.9.9</version></parent><version>1.2.0</version></project>",
+		"version.go":     "package main\n\nconst Dep = \"9.9.9\"\n\nconst Version = \"v1.2.0\"\n",
+		"VERSION":        "1.2.0\n",
+		"README.md":      "Dependency 9.9.9\nCurrent version: v1.2.0\n",
+		"empty.json":     `{"name": "thing"}`,
+		"no-version.txt": "nothing here\n",
+	}
+	for name, content := range files {
+		must(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	tests := []string{
+		"package.json",
+		"Chart.yaml",
+		"Cargo.toml",
+		"pom.xml",
+		"version.go:Version",
+		"VERSION",
+		"README.md:anchor=^Current version",
+	}
+	for _, spec := range tests {
+		t.Run(spec, func(t *testing.T) {
+			v, err := ReadVersion(filepath.Join(dir, spec), versions.SemVer{})
+			assert.NoError(t, err)
+			assert.Equal(t, "1.2.0", v.String())
+		})
+	}
+
+	t.Run("scheme", func(t *testing.T) {
+		calver, err := versions.NewScheme("calver", "YYYY.0M.MICRO")
+		must(t, err)
+
+		_, err = ReadVersion(filepath.Join(dir, "VERSION"), calver)
+		assert.Error(t, err)
+	})
+
+	for _, spec := range []string{"empty.json", "no-version.txt"} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := ReadVersion(filepath.Join(dir, spec), versions.SemVer{})
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
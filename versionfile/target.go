@@ -0,0 +1,348 @@
+package versionfile
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Masterminds/semver"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// ErrNotFound is returned when a selector does not find a value in a file
+var ErrNotFound = errors.New("version not found")
+
+// span is the byte range of a value within a file, excluding any quotes
+type span struct {
+	start, end int
+}
+
+// format finds version values within the content of a file of a particular type
+type format interface {
+	// locate returns the spans of the values at the selector, or ErrNotFound
+	locate(content []byte, selector string) ([]span, error)
+}
+
+var formats = map[string]format{
+	"json": jsonFormat{},
+	"yaml": yamlFormat{},
+	"toml": tomlFormat{},
+	"xml":  xmlFormat{},
+	"go":   goFormat{},
+	"text": textFormat{},
+}
+
+// Target is a file, the format in which it is written and the places the version is kept within it
+type Target struct {
+	Path      string
+	Format    string
+	Selectors []string
+	// Explicit is true if the selectors were given rather than defaulted, in which case every one must be found
+	Explicit bool
+	Rules
+}
+
+// Rules restrict which occurrences of a version are replaced, and how
+type Rules struct {
+	// Anchor, if set, restricts replacement to lines matching it
+	Anchor *regexp.Regexp
+	// Marker, if set, restricts replacement to lines containing it, or the line following it if that has no version
+	Marker string
+	// First replaces only the first occurrence
+	First bool
+	// Template, if set, is the text/template for the replacement, e.g. "v{{.Version}}"
+	Template string
+	// Required fails the replacement if no occurrence is found
+	Required bool
+}
+
+// DefaultMarker is the marker used by the "marker" rule when no marker text is given
+const DefaultMarker = "changetool:version"
+
+// Parse interprets a target specification of the form FILE or FILE:SELECTOR[,SELECTOR...].  When no selector is
+// given, the format and selectors are chosen from the file name, e.g. ".version" for package.json.  The format of a
+// selector depends on the file type:  a dotted key path for JSON, YAML and TOML (".version", "package.version"), a
+// slash separated element path for XML ("/project/version") and a const or var name for go source ("Version").
+//
+// Rules may be given in place of, or as well as, selectors:  "anchor=REGEXP", "marker" or "marker=TEXT", "first",
+// "template=TEMPLATE" and "required".  A comma within a rule is written as "\,".
+func Parse(spec string) (Target, error) {
+	filename, selectors := spec, ""
+	if _, err := os.Stat(spec); err != nil {
+		skip := 0
+		if len(spec) > 2 && spec[1] == ':' {
+			// a windows drive letter
+			skip = 2
+		}
+		if n := strings.Index(spec[skip:], ":"); n >= 0 {
+			filename, selectors = spec[:skip+n], spec[skip+n+1:]
+		}
+	}
+
+	t := defaultTarget(filename)
+
+	var given []string
+	for _, element := range splitSelectors(selectors) {
+		isRule, err := t.Rules.parse(element)
+		switch {
+		case err != nil:
+			return t, fmt.Errorf("%s: %w", spec, err)
+		case !isRule:
+			given = append(given, element)
+		}
+	}
+
+	if len(given) > 0 {
+		if t.Format == "text" {
+			return t, fmt.Errorf("%s: selectors are not supported for this type of file", spec)
+		}
+		t.Selectors = given
+		t.Explicit = true
+	}
+
+	if t.Format == "go" && len(t.Selectors) == 0 {
+		// There's no conventional name for a version in go source, so without a selector treat it as text
+		t.Format = "text"
+	}
+
+	return t, nil
+}
+
+// parse sets the rule described by the element, returning false if the element is not a rule
+func (r *Rules) parse(element string) (bool, error) {
+	name, value := element, ""
+	if n := strings.Index(element, "="); n >= 0 {
+		name, value = element[:n], element[n+1:]
+	}
+
+	switch name {
+	case "anchor":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return true, err
+		}
+		r.Anchor = re
+	case "marker":
+		r.Marker = value
+		if r.Marker == "" {
+			r.Marker = DefaultMarker
+		}
+	case "first":
+		r.First = true
+	case "template":
+		if _, err := template.New("replacement").Parse(value); err != nil {
+			return true, err
+		}
+		r.Template = value
+	case "required":
+		r.Required = true
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// splitSelectors splits a comma separated list, where "\," is a literal comma
+func splitSelectors(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	var list []string
+	var current strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == ',':
+			current.WriteByte(',')
+			i++
+		case s[i] == ',':
+			list = append(list, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(s[i])
+		}
+	}
+
+	return append(list, current.String())
+}
+
+// defaultTarget chooses the format and selectors for a file from its name
+func defaultTarget(filename string) Target {
+	t := Target{Path: filename, Format: "text"}
+
+	base := filepath.Base(filename)
+	switch strings.ToLower(filepath.Ext(base)) {
+	case ".json":
+		t.Format, t.Selectors = "json", []string{".version"}
+	case ".yaml", ".yml":
+		t.Format, t.Selectors = "yaml", []string{".version"}
+		if base == "Chart.yaml" {
+			t.Selectors = append(t.Selectors, ".appVersion")
+		}
+	case ".toml":
+		t.Format, t.Selectors = "toml", []string{"package.version", "project.version", "tool.poetry.version"}
+	case ".xml":
+		t.Format, t.Selectors = "xml", []string{"/project/version"}
+	case ".go":
+		t.Format = "go"
+	}
+
+	return t
+}
+
+// String returns the target in the form accepted by Parse
+func (t Target) String() string {
+	if len(t.Selectors) == 0 {
+		return t.Path
+	}
+	return t.Path + ":" + strings.Join(t.Selectors, ",")
+}
+
+// locate finds the spans of all version values in the content
+func (t Target) locate(content []byte) ([]span, error) {
+	f, found := formats[t.Format]
+	if !found {
+		return nil, fmt.Errorf("unknown file format %s", t.Format)
+	}
+
+	if len(t.Selectors) == 0 {
+		return f.locate(content, "")
+	}
+
+	var spans []span
+	for _, selector := range t.Selectors {
+		found, err := f.locate(content, selector)
+		switch {
+		case errors.Is(err, ErrNotFound) && !t.Explicit:
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("%s: %s: %w", t.Path, selector, err)
+		}
+		spans = append(spans, found...)
+	}
+
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("%s: %s: %w", t.Path, strings.Join(t.Selectors, ","), ErrNotFound)
+	}
+
+	return spans, nil
+}
+
+// filter applies the rules to the spans found in the content
+func (r Rules) filter(content []byte, spans []span) []span {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	if r.Anchor != nil || r.Marker != "" {
+		allowed := r.allowedLines(content, spans)
+		var kept []span
+		for _, s := range spans {
+			if allowed[lineOf(content, s.start)] {
+				kept = append(kept, s)
+			}
+		}
+		spans = kept
+	}
+
+	if r.First && len(spans) > 1 {
+		spans = spans[:1]
+	}
+
+	return spans
+}
+
+// allowedLines returns the (0 based) numbers of the lines on which replacement is allowed
+func (r Rules) allowedLines(content []byte, spans []span) map[int]bool {
+	hasVersion := make(map[int]bool)
+	for _, s := range spans {
+		hasVersion[lineOf(content, s.start)] = true
+	}
+
+	allowed := make(map[int]bool)
+	for n, line := range strings.Split(string(content), "\n") {
+		if r.Anchor != nil && !r.Anchor.MatchString(line) {
+			continue
+		}
+		if r.Marker != "" && !strings.Contains(line, r.Marker) {
+			continue
+		}
+		if r.Marker != "" && !hasVersion[n] {
+			allowed[n+1] = true
+		} else {
+			allowed[n] = true
+		}
+	}
+
+	return allowed
+}
+
+// lineOf returns the 0 based line number of the offset
+func lineOf(content []byte, offset int) int {
+	return strings.Count(string(content[:offset]), "\n")
+}
+
+// replacement returns the text which replaces each occurrence of the version
+func (r Rules) replacement(version string) (string, error) {
+	if r.Template == "" {
+		return version, nil
+	}
+
+	data := struct {
+		Version              string
+		Major, Minor, Patch  int64
+		Prerelease, Metadata string
+	}{Version: version}
+
+	if v, err := semver.NewVersion(version); err == nil {
+		data.Major, data.Minor, data.Patch = v.Major(), v.Minor(), v.Patch()
+		data.Prerelease, data.Metadata = v.Prerelease(), v.Metadata()
+	}
+
+	tmpl, err := template.New("replacement").Parse(r.Template)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err = tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// Apply returns the content with every version value replaced by the given version, leaving all other text as it was
+func (t Target) Apply(content []byte, version string) ([]byte, error) {
+	spans, err := t.locate(content)
+	if err != nil {
+		return nil, err
+	}
+
+	spans = t.Rules.filter(content, spans)
+	if len(spans) == 0 && t.Required {
+		return nil, fmt.Errorf("%s: %w", t.Path, ErrNotFound)
+	}
+
+	replacement, err := t.Rules.replacement(version)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	last := 0
+	for _, s := range spans {
+		out = append(out, content[last:s.start]...)
+		out = append(out, replacement...)
+		last = s.end
+	}
+
+	return append(out, content[last:]...), nil
+}
+
+// splitPath splits a dotted selector such as ".package.version" into its keys
+func splitPath(selector string) []string {
+	return strings.Split(strings.TrimPrefix(selector, "."), ".")
+}
@@ -0,0 +1,276 @@
+package versionfile
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Target
+	}{
+		{"package.json", Target{Path: "package.json", Format: "json", Selectors: []string{".version"}}},
+		{"charts/app/Chart.yaml", Target{Path: "charts/app/Chart.yaml", Format: "yaml", Selectors: []string{".version", ".appVersion"}}},
+		{"Cargo.toml", Target{Path: "Cargo.toml", Format: "toml", Selectors: []string{"package.version", "project.version", "tool.poetry.version"}}},
+		{"pom.xml", Target{Path: "pom.xml", Format: "xml", Selectors: []string{"/project/version"}}},
+		{"version.go", Target{Path: "version.go", Format: "text"}},
+		{"version.go:Version", Target{Path: "version.go", Format: "go", Selectors: []string{"Version"}, Explicit: true}},
+		{"values.yaml:.image.tag,.appVersion", Target{Path: "values.yaml", Format: "yaml", Selectors: []string{".image.tag", ".appVersion"}, Explicit: true}},
+		{"README.md", Target{Path: "README.md", Format: "text"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := Parse(tt.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("selector on text", func(t *testing.T) {
+		_, err := Parse("README.md:.version")
+		assert.Error(t, err)
+	})
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		content string
+		want    string
+	}{
+		{
+			name: "package.json",
+			spec: "package.json",
+			content: `{
+  "name": "thing",
+  "version":   "1.2.0",
+  "dependencies": {"left-pad": "1.3.0", "version": "9.9.9"},
+  "list": [{"version": "8.8.8"}]
+}
+`,
+			want: `{
+  "name": "thing",
+  "version":   "1.3.0",
+  "dependencies": {"left-pad": "1.3.0", "version": "9.9.9"},
+  "list": [{"version": "8.8.8"}]
+}
+`,
+		},
+		{
+			name:    "json nested path",
+			spec:    "data.json:.list.1.version",
+			content: `{"list": [{"version": "1.0.0"}, {"version": "1.2.0"}]}`,
+			want:    `{"list": [{"version": "1.0.0"}, {"version": "1.3.0"}]}`,
+		},
+		{
+			name: "Chart.yaml",
+			spec: "Chart.yaml",
+			content: `apiVersion: v2
+name: app
+# the chart version
+version: 1.2.0
+appVersion: "1.2.0"
+dependencies:
+  - name: redis
+    version: 1.2.0
+`,
+			want: `apiVersion: v2
+name: app
+# the chart version
+version: 1.3.0
+appVersion: "1.3.0"
+dependencies:
+  - name: redis
+    version: 1.2.0
+`,
+		},
+		{
+			name: "Cargo.toml",
+			spec: "Cargo.toml",
+			content: `[package]
+name = "thing"
+version = "1.2.0"   # the version
+
+[dependencies]
+serde = { version = "1.2.0" }
+version = "1.2.0"
+`,
+			want: `[package]
+name = "thing"
+version = "1.3.0"   # the version
+
+[dependencies]
+serde = { version = "1.2.0" }
+version = "1.2.0"
+`,
+		},
+		{
+			name: "pyproject.toml",
+			spec: "pyproject.toml",
+			content: `[tool.poetry]
+name = 'thing'
+version = '1.2.0'
+`,
+			want: `[tool.poetry]
+name = 'thing'
+version = '1.3.0'
+`,
+		},
+		{
+			name: "pom.xml",
+			spec: "pom.xml",
+			content: `<?xml version="1.0" encoding="UTF-8"?>
+<project>
+  <parent>
+    <version>1.2.0</version>
+  </parent>
+  <version>
+    1.2.0
+  </version>
+  <dependencies><dependency><version>1.2.0</version></dependency></dependencies>
+</project>
+`,
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<project>
+  <parent>
+    <version>1.2.0</version>
+  </parent>
+  <version>
+    1.3.0
+  </version>
+  <dependencies><dependency><version>1.2.0</version></dependency></dependencies>
+</project>
+`,
+		},
+		{
+			name:    "go source",
+			spec:    "version.go:Version",
+			content: "package main\n\nconst Other = \"1.2.0\"\n\nvar (\n\tName, Version = \"x\", `1.2.0`\n)\n",
+			want:    "package main\n\nconst Other = \"1.2.0\"\n\nvar (\n\tName, Version = \"x\", `1.3.0`\n)\n",
+		},
+		{
+			name:    "text",
+			spec:    "README.md",
+			content: "Install v1.2.0 (was 1.1.0)\nno newline",
+			want:    "Install 1.3.0 (was 1.3.0)\nno newline",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := Parse(tt.spec)
+			assert.NoError(t, err)
+
+			got, err := target.Apply([]byte(tt.content), "1.3.0")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestApplyNotFound(t *testing.T) {
+	tests := []struct {
+		spec    string
+		content string
+	}{
+		{"package.json", `{"name": "thing"}`},
+		{"data.json:.name,.version", `{"name": "thing"}`},
+		{"Chart.yaml", "name: app\n"},
+		{"Cargo.toml", "[dependencies]\nversion = \"1.0.0\"\n"},
+		{"pom.xml", "<project><parent><version>1.0.0</version></parent></project>"},
+		{"version.go:Version", "package main\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			target, err := Parse(tt.spec)
+			assert.NoError(t, err)
+
+			_, err = target.Apply([]byte(tt.content), "1.3.0")
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	readme := `# Project
+
+Current version: v1.2.0
+
+<!-- changetool:version -->
+    go install example.com/project@v1.2.0
+
+## History
+
+* v1.2.0 fixed things
+* v1.1.0 added things
+`
+
+	tests := []struct {
+		name    string
+		spec    string
+		content string
+		want    string
+	}{
+		{
+			name:    "anchor",
+			spec:    `README.md:anchor=^Current version:,template=v{{.Version}}`,
+			content: readme,
+			want:    strings.Replace(readme, "Current version: v1.2.0", "Current version: v1.3.0", 1),
+		},
+		{
+			name:    "marker on previous line",
+			spec:    `README.md:marker,template=v{{.Version}}`,
+			content: readme,
+			want:    strings.Replace(readme, "project@v1.2.0", "project@v1.3.0", 1),
+		},
+		{
+			name:    "marker on same line",
+			spec:    `version.go:marker`,
+			content: "package main\n\nconst Dep = \"1.0.0\"\n\nconst Version = \"1.2.0\" // changetool:version\n",
+			want:    "package main\n\nconst Dep = \"1.0.0\"\n\nconst Version = \"1.3.0\" // changetool:version\n",
+		},
+		{
+			name:    "first",
+			spec:    `README.md:first`,
+			content: "1.2.0 and 1.1.0\n",
+			want:    "1.3.0 and 1.1.0\n",
+		},
+		{
+			name:    "escaped comma in anchor",
+			spec:    `README.md:anchor=^x{1\,2}:`,
+			content: "xx: 1.2.0\nxxx: 1.2.0\n",
+			want:    "xx: 1.3.0\nxxx: 1.2.0\n",
+		},
+		{
+			name:    "template on structured file",
+			spec:    `values.yaml:.image.tag,template={{.Major}}.{{.Minor}}`,
+			content: "image:\n  tag: \"1.2\"\n",
+			want:    "image:\n  tag: \"1.3\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := Parse(tt.spec)
+			assert.NoError(t, err)
+
+			got, err := target.Apply([]byte(tt.content), "1.3.0")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+
+	t.Run("required", func(t *testing.T) {
+		target, err := Parse(`README.md:anchor=^Released:,required`)
+		assert.NoError(t, err)
+
+		_, err = target.Apply([]byte(readme), "1.3.0")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("bad anchor", func(t *testing.T) {
+		_, err := Parse(`README.md:anchor=(`)
+		assert.Error(t, err)
+	})
+}
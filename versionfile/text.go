@@ -0,0 +1,16 @@
+package versionfile
+
+import (
+	"github.com/deweysasser/changetool/versions"
+)
+
+// textFormat finds every semver looking string in the file
+type textFormat struct{}
+
+func (textFormat) locate(content []byte, _ string) ([]span, error) {
+	var spans []span
+	for _, m := range versions.SemverRegexp.FindAllIndex(content, -1) {
+		spans = append(spans, span{m[0], m[1]})
+	}
+	return spans, nil
+}
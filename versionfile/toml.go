@@ -0,0 +1,69 @@
+package versionfile
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// tomlFormat finds string values by dotted key path, e.g. "package.version" for Cargo.toml or "project.version" for
+// pyproject.toml.  Only the common layout of "[table]" headers followed by `key = "value"` lines is understood.
+type tomlFormat struct{}
+
+var (
+	tomlTable    = regexp.MustCompile(`^\s*\[\s*([^\[\]]+?)\s*\]\s*(#.*)?$`)
+	tomlArray    = regexp.MustCompile(`^\s*\[\[`)
+	tomlKeyValue = regexp.MustCompile(`^\s*([A-Za-z0-9_.-]+|"[^"]*")\s*=\s*(?:"([^"\\]*)"|'([^']*)')`)
+)
+
+func (tomlFormat) locate(content []byte, selector string) ([]span, error) {
+	target := strings.TrimPrefix(selector, ".")
+	table := ""
+	offset := 0
+
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		start := offset
+		offset += len(line)
+
+		if tomlArray.Match(line) {
+			// values in arrays of tables can't be selected
+			table = "[["
+			continue
+		}
+
+		if m := tomlTable.FindSubmatch(line); m != nil {
+			table = normalizeTOMLKey(string(m[1]))
+			continue
+		}
+
+		m := tomlKeyValue.FindSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+
+		key := normalizeTOMLKey(string(line[m[2]:m[3]]))
+		if table != "" {
+			key = table + "." + key
+		}
+
+		if key != target {
+			continue
+		}
+
+		if m[4] >= 0 {
+			return []span{{start + m[4], start + m[5]}}, nil
+		}
+		return []span{{start + m[6], start + m[7]}}, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+// normalizeTOMLKey removes the whitespace and quotes from a (possibly dotted) key
+func normalizeTOMLKey(key string) string {
+	parts := strings.Split(key, ".")
+	for n, p := range parts {
+		parts[n] = strings.Trim(strings.TrimSpace(p), `"`)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,187 @@
+package versionfile
+
+import (
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/rs/zerolog/log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Change is the update of a single file
+type Change struct {
+	Target
+	Mode     os.FileMode
+	Original []byte
+	Updated  []byte
+	// Created is true if the file does not exist yet
+	Created bool
+}
+
+// Changed is true if the update alters the file
+func (c Change) Changed() bool {
+	return c.Created || string(c.Original) != string(c.Updated)
+}
+
+// Diff returns the unified diff of the change, or "" if there is none
+func (c Change) Diff() (string, error) {
+	if !c.Changed() {
+		return "", nil
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(c.Original),
+		B:        splitLines(c.Updated),
+		FromFile: c.Path,
+		ToFile:   c.Path,
+		Context:  3,
+	})
+}
+
+// splitLines splits the content into lines, each ending in a newline
+func splitLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// Prepare calculates the changes which put the version into each of the targets, without writing anything.  A file
+// given by more than one target has a single change making all their updates.
+func Prepare(specs []string, version string) ([]Change, error) {
+	var changes []Change
+	byPath := make(map[string]int)
+
+	for _, spec := range specs {
+		target, err := Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		if n, found := byPath[filepath.Clean(target.Path)]; found {
+			if changes[n].Updated, err = target.Apply(changes[n].Updated, version); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		info, err := os.Stat(target.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		// #nosec G304
+		content, err := os.ReadFile(target.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err := target.Apply(content, version)
+		if err != nil {
+			return nil, err
+		}
+
+		byPath[filepath.Clean(target.Path)] = len(changes)
+		changes = append(changes, Change{Target: target, Mode: info.Mode().Perm(), Original: content, Updated: updated})
+	}
+
+	return changes, nil
+}
+
+// Diff returns the unified diff of all the changes
+func Diff(changes []Change) (string, error) {
+	var b strings.Builder
+	for _, c := range changes {
+		d, err := c.Diff()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(d)
+	}
+	return b.String(), nil
+}
+
+// WriteAll writes every change, or none of them.  Each file is first written to a temporary file beside it, keeping
+// the original permissions, and only when all have been written are they renamed into place.  If a rename fails, the
+// files already replaced are restored.
+func WriteAll(changes []Change) error {
+	var pending []Change
+	var temps []string
+
+	defer func() {
+		for _, tmp := range temps {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	for _, c := range changes {
+		if !c.Changed() {
+			continue
+		}
+
+		tmp, err := writeTemp(c)
+		if err != nil {
+			return err
+		}
+
+		pending = append(pending, c)
+		temps = append(temps, tmp)
+	}
+
+	for n, c := range pending {
+		if err := os.Rename(temps[n], c.Path); err != nil {
+			Restore(pending[:n])
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Restore writes the original content back into the files of the changes, removing those which were created
+func Restore(changes []Change) {
+	for _, c := range changes {
+		if c.Created {
+			if err := os.Remove(c.Path); err != nil && !os.IsNotExist(err) {
+				log.Err(err).Str("file", c.Path).Msg("Unable to remove file")
+			}
+			continue
+		}
+
+		// #nosec G306
+		if err := os.WriteFile(c.Path, c.Original, c.Mode); err != nil {
+			log.Err(err).Str("file", c.Path).Msg("Unable to restore file")
+		}
+	}
+}
+
+// writeTemp writes the updated content to a temporary file in the same directory as the target
+func writeTemp(c Change) (string, error) {
+	fp, err := os.CreateTemp(filepath.Dir(c.Path), "."+filepath.Base(c.Path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	name := fp.Name()
+
+	if _, err = fp.Write(c.Updated); err != nil {
+		_ = fp.Close()
+		_ = os.Remove(name)
+		return "", err
+	}
+
+	if err = fp.Close(); err != nil {
+		_ = os.Remove(name)
+		return "", err
+	}
+
+	if err = os.Chmod(name, c.Mode); err != nil {
+		_ = os.Remove(name)
+		return "", fmt.Errorf("unable to set permissions of %s: %w", c.Path, err)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,103 @@
+package versionfile
+
+import (
+	"github.com/deweysasser/changetool/test_framework"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAll(t *testing.T) {
+	dir := test_framework.TestDir(t)
+	script := filepath.Join(dir, "release.sh")
+	chart := filepath.Join(dir, "Chart.yaml")
+
+	must(t, os.WriteFile(script, []byte("#!/bin/sh\nVERSION=1.2.0\n"), 0700))
+	must(t, os.Chmod(script, 0751))
+	must(t, os.WriteFile(chart, []byte("name: app\nversion: 1.2.0\n"), 0640))
+
+	t.Run("dry run diff", func(t *testing.T) {
+		changes, err := Prepare([]string{script, chart}, "1.3.0")
+		must(t, err)
+
+		diff, err := Diff(changes)
+		must(t, err)
+		assert.Equal(t, "--- "+script+`
++++ `+script+`
+@@ -1,2 +1,2 @@
+ #!/bin/sh
+-VERSION=1.2.0
++VERSION=1.3.0
+--- `+chart+`
++++ `+chart+`
+@@ -1,2 +1,2 @@
+ name: app
+-version: 1.2.0
++version: 1.3.0
+`, diff)
+	})
+
+	t.Run("nothing written when one file fails", func(t *testing.T) {
+		_, err := Prepare([]string{script, chart + ":.missing"}, "1.3.0")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		bytes, err := os.ReadFile(script)
+		must(t, err)
+		assert.Equal(t, "#!/bin/sh\nVERSION=1.2.0\n", string(bytes))
+	})
+
+	t.Run("write keeps permissions", func(t *testing.T) {
+		changes, err := Prepare([]string{script, chart}, "1.3.0")
+		must(t, err)
+		must(t, WriteAll(changes))
+
+		bytes, err := os.ReadFile(script)
+		must(t, err)
+		assert.Equal(t, "#!/bin/sh\nVERSION=1.3.0\n", string(bytes))
+
+		info, err := os.Stat(script)
+		must(t, err)
+		assert.Equal(t, os.FileMode(0751), info.Mode().Perm())
+
+		info, err = os.Stat(chart)
+		must(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+		entries, err := os.ReadDir(dir)
+		must(t, err)
+		assert.Len(t, entries, 2, "no temporary files are left behind")
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		changes, err := Prepare([]string{script}, "1.4.0")
+		must(t, err)
+		must(t, WriteAll(changes))
+
+		Restore(changes)
+
+		bytes, err := os.ReadFile(script)
+		must(t, err)
+		assert.Equal(t, "#!/bin/sh\nVERSION=1.3.0\n", string(bytes))
+	})
+
+	t.Run("file named twice", func(t *testing.T) {
+		packageJSON := filepath.Join(t.TempDir(), "package.json")
+		must(t, os.WriteFile(packageJSON, []byte(`{"version": "1.2.0", "other": {"version": "1.2.0"}}`+"\n"), 0600))
+
+		changes, err := Prepare([]string{packageJSON + ":.version", packageJSON + ":.other.version"}, "1.4.0")
+		must(t, err)
+		assert.Len(t, changes, 1)
+		must(t, WriteAll(changes))
+
+		bytes, err := os.ReadFile(packageJSON)
+		must(t, err)
+		assert.Equal(t, `{"version": "1.4.0", "other": {"version": "1.4.0"}}`+"\n", string(bytes))
+	})
+}
+
+func must(t *testing.T, err error) {
+	if err != nil {
+		t.Fatal(err)
+	}
+}
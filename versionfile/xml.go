@@ -0,0 +1,55 @@
+package versionfile
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlFormat finds element text by slash separated element path, e.g. "/project/version" for a maven pom.xml
+type xmlFormat struct{}
+
+func (xmlFormat) locate(content []byte, selector string) ([]span, error) {
+	target := strings.Trim(selector, "/")
+
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	var stack []string
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			if strings.Join(stack, "/") != target {
+				continue
+			}
+
+			start := int(dec.InputOffset())
+			text, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := text.(xml.CharData); !ok {
+				return nil, fmt.Errorf("element has no text")
+			}
+			end := int(dec.InputOffset())
+
+			raw := content[start:end]
+			trimmedStart := len(raw) - len(bytes.TrimLeft(raw, " \t\r\n"))
+			trimmedEnd := len(bytes.TrimRight(raw, " \t\r\n"))
+
+			return []span{{start + trimmedStart, start + trimmedEnd}}, nil
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
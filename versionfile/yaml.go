@@ -0,0 +1,84 @@
+package versionfile
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"strconv"
+)
+
+// yamlFormat finds scalar values by dotted key path, e.g. ".appVersion".  Sequence elements are selected by index.
+type yamlFormat struct{}
+
+func (yamlFormat) locate(content []byte, selector string) ([]span, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	if len(doc.Content) == 0 {
+		return nil, ErrNotFound
+	}
+
+	node := doc.Content[0]
+	for _, key := range splitPath(selector) {
+		node = yamlChild(node, key)
+		if node == nil {
+			return nil, ErrNotFound
+		}
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("value is not a scalar")
+	}
+
+	start := lineOffset(content, node.Line) + node.Column - 1
+	if start >= len(content) {
+		return nil, fmt.Errorf("unable to find value at line %d", node.Line)
+	}
+
+	if quote := content[start]; quote == '"' || quote == '\'' {
+		end := bytes.IndexByte(content[start+1:], quote)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string at line %d", node.Line)
+		}
+		return []span{{start + 1, start + 1 + end}}, nil
+	}
+
+	end := start + len(node.Value)
+	if end > len(content) || string(content[start:end]) != node.Value {
+		return nil, fmt.Errorf("unsupported scalar style at line %d", node.Line)
+	}
+
+	return []span{{start, end}}, nil
+}
+
+// yamlChild returns the value of the key in a mapping, or the indexed element of a sequence
+func yamlChild(node *yaml.Node, key string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if n, err := strconv.Atoi(key); err == nil && n >= 0 && n < len(node.Content) {
+			return node.Content[n]
+		}
+	}
+	return nil
+}
+
+// lineOffset returns the byte offset of the start of the 1 based line
+func lineOffset(content []byte, line int) int {
+	offset := 0
+	for l := 1; l < line; l++ {
+		n := bytes.IndexByte(content[offset:], '\n')
+		if n < 0 {
+			return len(content)
+		}
+		offset += n + 1
+	}
+	return offset
+}
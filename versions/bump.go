@@ -0,0 +1,50 @@
+package versions
+
+import (
+	"fmt"
+	"github.com/Masterminds/semver"
+	"strings"
+)
+
+// Bump is the level by which a version is incremented
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+var bumpNames = []string{"none", "patch", "minor", "major"}
+
+func (b Bump) String() string {
+	if b < BumpNone || b > BumpMajor {
+		return fmt.Sprintf("Bump(%d)", int(b))
+	}
+	return bumpNames[b]
+}
+
+// ParseBump converts the name of a bump level into a Bump
+func ParseBump(s string) (Bump, error) {
+	for n, name := range bumpNames {
+		if strings.EqualFold(s, name) {
+			return Bump(n), nil
+		}
+	}
+	return BumpNone, fmt.Errorf("unknown bump level %s", s)
+}
+
+// Apply increments the version by the bump level
+func (b Bump) Apply(v semver.Version) semver.Version {
+	switch b {
+	case BumpMajor:
+		return v.IncMajor()
+	case BumpMinor:
+		return v.IncMinor()
+	case BumpPatch:
+		return v.IncPatch()
+	default:
+		return v
+	}
+}
@@ -10,13 +10,37 @@ import (
 	"github.com/rs/zerolog/log"
 	"os"
 	"regexp"
+	"strings"
 )
 
 var SemverRegexp = regexp.MustCompile(`v?([0-9]+)\.([0-9]+)\.([0-9]+)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+)?`)
 
 func FindPreviousVersionFromTag(r *repo.Repository) (version semver.Version, foundTag string, errReturn error) {
+	return FindPreviousVersionFromTagPrefix(r, "")
+}
+
+// FindPreviousVersionFromTagPrefix finds the most recent version tag which starts with the given prefix (e.g.
+// "sub/module/" for nested go modules), interpreting the remainder of the tag as the version
+func FindPreviousVersionFromTagPrefix(r *repo.Repository, prefix string) (version semver.Version, foundTag string, errReturn error) {
+	return FindPreviousVersionFromTagScheme(r, prefix, SemVer{})
+}
+
+// FindPreviousVersionFromTagScheme finds the most recent version tag which starts with the given prefix and whose
+// remainder is a version in the scheme.  Tags which are not versions of the scheme are ignored.
+func FindPreviousVersionFromTagScheme(r *repo.Repository, prefix string, scheme Scheme) (version semver.Version, foundTag string, errReturn error) {
+	return findPreviousVersion(r, prefix, scheme, func(semver.Version) bool { return true })
+}
+
+// FindPreviousVersionInLine finds the most recent version tag of the scheme, like FindPreviousVersionFromTagScheme,
+// ignoring versions outside the maintenance line
+func FindPreviousVersionInLine(r *repo.Repository, prefix string, scheme Scheme, line Line) (version semver.Version, foundTag string, errReturn error) {
+	return findPreviousVersion(r, prefix, scheme, line.Contains)
+}
+
+// findPreviousVersion finds the most recent version tag of the scheme whose version is accepted
+func findPreviousVersion(r *repo.Repository, prefix string, scheme Scheme, accept func(semver.Version) bool) (version semver.Version, foundTag string, errReturn error) {
 	version = semver.Version{}
-	log.Debug().Msg("finding previous version by examining tags")
+	log.Debug().Str("prefix", prefix).Str("scheme", scheme.Name()).Msg("finding previous version by examining tags")
 
 	commits, err := r.Log(&git.LogOptions{Order: git.LogOrderCommitterTime})
 	if err != nil {
@@ -35,9 +59,12 @@ func FindPreviousVersionFromTag(r *repo.Repository) (version semver.Version, fou
 			Msg("Looking up commit")
 		for _, tag := range reverseTagMap[commit.Hash] {
 			log.Debug().Str("tag", tag).Str("regex", SemverRegexp.String()).Msg("Matching against tag")
-			if v, err := semver.NewVersion(tag); err == nil {
+			if !strings.HasPrefix(tag, prefix) {
+				continue
+			}
+			if v, err := scheme.Parse(tag[len(prefix):]); err == nil && accept(v) {
 				if v.GreaterThan(&version) {
-					version = *v
+					version = v
 					foundTag = tag
 				}
 			}
@@ -52,6 +79,9 @@ func FindPreviousVersionFromTag(r *repo.Repository) (version semver.Version, fou
 	return version, foundTag, nil
 }
 
+// FindPreviousVersionFromFile returns the first semver looking string in the file.
+//
+// Deprecated: use versionfile.ReadVersion, which understands structured files.
 func FindPreviousVersionFromFile(filename string) (semver.Version, string, error) {
 	version := semver.Version{}
 	// #nosec G304
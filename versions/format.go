@@ -0,0 +1,190 @@
+package versions
+
+import (
+	"fmt"
+	"github.com/Masterminds/semver"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Formatter converts a version to the representation used by a packaging ecosystem.  Some ecosystems use more than
+// one value (e.g. RPM version and release), so a list is returned.
+type Formatter func(v semver.Version) []string
+
+// Formats maps the name of a version format to its Formatter
+var Formats = map[string]Formatter{
+	"semver": FormatSemver,
+	"pep440": FormatPEP440,
+	"debian": FormatDebian,
+	"rpm":    FormatRPM,
+	"nuget":  FormatNuGet,
+	"maven":  FormatMaven,
+	"docker": FormatDocker,
+}
+
+// FormatNames returns the names of all version formats, sorted
+func FormatNames() []string {
+	var names []string
+	for name := range Formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Format converts the version using the named format
+func Format(name string, v semver.Version) ([]string, error) {
+	if f, found := Formats[name]; found {
+		return f(v), nil
+	}
+	return nil, fmt.Errorf("unknown version format %s", name)
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^0-9A-Za-z]+`)
+
+func release(v semver.Version) string {
+	return fmt.Sprintf("%d.%d.%d", v.Major(), v.Minor(), v.Patch())
+}
+
+// FormatSemver is the semantic version itself
+func FormatSemver(v semver.Version) []string {
+	return []string{v.String()}
+}
+
+// pep440Phases maps semver prerelease identifiers to PEP 440 pre, post and development release segments
+var pep440Phases = map[string]string{
+	"a":       "a",
+	"alpha":   "a",
+	"b":       "b",
+	"beta":    "b",
+	"c":       "rc",
+	"rc":      "rc",
+	"pre":     "rc",
+	"preview": "rc",
+	"post":    ".post",
+	"dev":     ".dev",
+}
+
+// FormatPEP440 converts to a python version, e.g. 1.2.0rc1, 1.2.0.dev3 or 1.2.0.dev0+dirty.abc123.  Prereleases which
+// have no PEP 440 equivalent become development releases with the prerelease as the local version label.
+func FormatPEP440(v semver.Version) []string {
+	s := release(v)
+	var local []string
+
+	if pre := v.Prerelease(); pre != "" {
+		parts := strings.Split(pre, ".")
+		if phase, found := pep440Phases[strings.ToLower(parts[0])]; found {
+			number := "0"
+			if len(parts) > 1 && isNumeric(parts[1]) {
+				number = parts[1]
+				parts = parts[2:]
+			} else {
+				parts = parts[1:]
+			}
+			s += phase + number
+		} else {
+			s += ".dev0"
+		}
+		local = append(local, parts...)
+	}
+
+	if v.Metadata() != "" {
+		local = append(local, v.Metadata())
+	}
+
+	if len(local) > 0 {
+		s += "+" + strings.Trim(nonAlphanumeric.ReplaceAllString(strings.Join(local, "."), "."), ".")
+	}
+
+	return []string{s}
+}
+
+// FormatDebian converts to a debian upstream version, where "~" sorts prereleases before the release, e.g. 1.2.0~rc.1
+func FormatDebian(v semver.Version) []string {
+	s := release(v)
+	if v.Prerelease() != "" {
+		s += "~" + strings.ReplaceAll(v.Prerelease(), "-", ".")
+	}
+	if v.Metadata() != "" {
+		s += "+" + strings.ReplaceAll(v.Metadata(), "-", ".")
+	}
+	return []string{s}
+}
+
+// FormatRPM converts to an RPM version and release.  Prereleases use a release below 1 so that the final release
+// sorts after them, e.g. 1.2.0 and 0.1.rc.1
+func FormatRPM(v semver.Version) []string {
+	rel := "1"
+	if v.Prerelease() != "" {
+		rel = "0.1." + nonAlphanumeric.ReplaceAllString(v.Prerelease(), ".")
+	}
+	if v.Metadata() != "" {
+		rel += "." + nonAlphanumeric.ReplaceAllString(v.Metadata(), ".")
+	}
+	return []string{release(v), rel}
+}
+
+// FormatNuGet converts to a SemVer 1.0 style version understood by all NuGet clients, e.g. 1.2.0-rc1
+func FormatNuGet(v semver.Version) []string {
+	s := release(v)
+	if v.Prerelease() != "" {
+		s += "-" + nonAlphanumeric.ReplaceAllString(v.Prerelease(), "")
+	}
+	return []string{s}
+}
+
+// mavenQualifiers are the prerelease qualifiers maven orders before a release
+var mavenQualifiers = map[string]bool{
+	"alpha":     true,
+	"a":         true,
+	"beta":      true,
+	"b":         true,
+	"milestone": true,
+	"m":         true,
+	"rc":        true,
+	"cr":        true,
+}
+
+// FormatMaven converts to a maven version.  Prereleases with a qualifier maven understands are kept, all others
+// become snapshots, e.g. 1.2.0-rc.1 or 1.2.0-SNAPSHOT
+func FormatMaven(v semver.Version) []string {
+	s := release(v)
+	if pre := v.Prerelease(); pre != "" {
+		if mavenQualifiers[strings.ToLower(strings.Split(pre, ".")[0])] {
+			s += "-" + pre
+		} else {
+			s += "-SNAPSHOT"
+		}
+	}
+	return []string{s}
+}
+
+// FormatDocker returns the image tags for the version.  A release is tagged with its major, major.minor and full
+// version and "latest", a prerelease only with its full version.
+func FormatDocker(v semver.Version) []string {
+	if v.Prerelease() != "" {
+		return []string{strings.ReplaceAll(v.String(), "+", "_")}
+	}
+
+	full := release(v)
+	if v.Metadata() != "" {
+		full += "_" + v.Metadata()
+	}
+
+	return []string{
+		fmt.Sprintf("%d", v.Major()),
+		fmt.Sprintf("%d.%d", v.Major(), v.Minor()),
+		full,
+		"latest",
+	}
+}
+
+func isNumeric(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
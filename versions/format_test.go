@@ -0,0 +1,52 @@
+package versions
+
+import (
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		version string
+		want    []string
+	}{
+		{"semver", "1.2", []string{"1.2.0"}},
+		{"semver", "1.2.0-rc.1+abc", []string{"1.2.0-rc.1+abc"}},
+		{"pep440", "1.2.0", []string{"1.2.0"}},
+		{"pep440", "1.2.0-rc.1", []string{"1.2.0rc1"}},
+		{"pep440", "1.2.0-alpha", []string{"1.2.0a0"}},
+		{"pep440", "1.2.0-beta.2", []string{"1.2.0b2"}},
+		{"pep440", "1.2.0-dev.3", []string{"1.2.0.dev3"}},
+		{"pep440", "1.2.0+abc123", []string{"1.2.0+abc123"}},
+		{"pep440", "1.3.0-dirty.abc123", []string{"1.3.0.dev0+dirty.abc123"}},
+		{"debian", "1.2.0", []string{"1.2.0"}},
+		{"debian", "1.2.0-rc.1", []string{"1.2.0~rc.1"}},
+		{"debian", "1.2.0-rc-1+build", []string{"1.2.0~rc.1+build"}},
+		{"rpm", "1.2.0", []string{"1.2.0", "1"}},
+		{"rpm", "1.2.0-rc.1", []string{"1.2.0", "0.1.rc.1"}},
+		{"nuget", "1.2.0", []string{"1.2.0"}},
+		{"nuget", "1.2.0-rc.1+abc", []string{"1.2.0-rc1"}},
+		{"maven", "1.2.0", []string{"1.2.0"}},
+		{"maven", "1.2.0-rc.1", []string{"1.2.0-rc.1"}},
+		{"maven", "1.3.0-dirty.abc123", []string{"1.3.0-SNAPSHOT"}},
+		{"docker", "1.2.3", []string{"1", "1.2", "1.2.3", "latest"}},
+		{"docker", "1.2.3-rc.1+abc", []string{"1.2.3-rc.1_abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.version, func(t *testing.T) {
+			v, err := semver.NewVersion(tt.version)
+			must(t, err)
+
+			got, err := Format(tt.format, *v)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := Format("unknown", semver.Version{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,76 @@
+package versions
+
+import (
+	"fmt"
+	"github.com/Masterminds/semver"
+	"strconv"
+	"strings"
+)
+
+// Line is a maintenance line of versions which share a major (and usually a minor) version, e.g. 1.4.x or 1.x
+type Line struct {
+	Major uint64
+	// Minor is the minor version of the line, or nil if any minor version belongs to it
+	Minor *uint64
+}
+
+// ParseLine interprets a line written as 1.4.x, 1.4, 1.x or 1
+func ParseLine(s string) (Line, error) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(parts) > 0 && (parts[len(parts)-1] == "x" || parts[len(parts)-1] == "*") {
+		parts = parts[:len(parts)-1]
+	}
+
+	if len(parts) < 1 || len(parts) > 2 {
+		return Line{}, fmt.Errorf("invalid version line %s: expected e.g. 1.4.x or 1.x", s)
+	}
+
+	major, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid version line %s: %w", s, err)
+	}
+
+	line := Line{Major: major}
+	if len(parts) == 2 {
+		minor, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return Line{}, fmt.Errorf("invalid version line %s: %w", s, err)
+		}
+		line.Minor = &minor
+	}
+
+	return line, nil
+}
+
+func (l Line) String() string {
+	if l.Minor == nil {
+		return fmt.Sprintf("%d.x", l.Major)
+	}
+	return fmt.Sprintf("%d.%d.x", l.Major, *l.Minor)
+}
+
+// Contains is true if the version belongs to the line
+func (l Line) Contains(v semver.Version) bool {
+	return v.Major() == int64(l.Major) && (l.Minor == nil || v.Minor() == int64(*l.Minor))
+}
+
+// MaxBump is the largest bump which keeps a version within the line
+func (l Line) MaxBump() Bump {
+	if l.Minor == nil {
+		return BumpMinor
+	}
+	return BumpPatch
+}
+
+// Exceeds is true if the version is greater than every version of the line, e.g. 2.0.0 exceeds 1.x and 1.5.0 exceeds
+// 1.4.x
+func (l Line) Exceeds(v semver.Version) bool {
+	switch {
+	case v.Major() != int64(l.Major):
+		return v.Major() > int64(l.Major)
+	case l.Minor == nil:
+		return false
+	default:
+		return v.Minor() > int64(*l.Minor)
+	}
+}
@@ -0,0 +1,54 @@
+package versions
+
+import (
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    string
+		max     Bump
+		in      string
+		out     string
+		wantErr bool
+	}{
+		{"1.4.x", "1.4.x", BumpPatch, "1.4.7", "1.5.0", false},
+		{"v1.4", "1.4.x", BumpPatch, "1.4.0-rc.1", "2.4.0", false},
+		{"1.x", "1.x", BumpMinor, "1.9.3", "2.0.0", false},
+		{"1", "1.x", BumpMinor, "1.0.0", "0.1.0", false},
+		{"1.4.2", "", BumpNone, "", "", true},
+		{"x", "", BumpNone, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			line, err := ParseLine(tt.line)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			must(t, err)
+
+			assert.Equal(t, tt.want, line.String())
+			assert.Equal(t, tt.max, line.MaxBump())
+			assert.True(t, line.Contains(*semver.MustParse(tt.in)))
+			assert.False(t, line.Contains(*semver.MustParse(tt.out)))
+		})
+	}
+}
+
+func TestLineExceeds(t *testing.T) {
+	major, err := ParseLine("1.x")
+	must(t, err)
+	minor, err := ParseLine("1.4.x")
+	must(t, err)
+
+	assert.False(t, major.Exceeds(*semver.MustParse("0.9.0")))
+	assert.False(t, major.Exceeds(*semver.MustParse("1.99.0")))
+	assert.True(t, major.Exceeds(*semver.MustParse("2.0.0")))
+	assert.False(t, minor.Exceeds(*semver.MustParse("1.4.9")))
+	assert.True(t, minor.Exceeds(*semver.MustParse("1.5.0")))
+	assert.False(t, minor.Exceeds(*semver.MustParse("0.7.0")))
+}
@@ -0,0 +1,40 @@
+package versions
+
+import (
+	"fmt"
+	"github.com/Masterminds/semver"
+	"github.com/deweysasser/changetool/repo"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/mod/module"
+	modsemver "golang.org/x/mod/semver"
+)
+
+// goVersion is semantic versioning limited to the canonical versions go accepts from tags, e.g. "v1.4.0" but not
+// "v1.4" or "1.4.0"
+type goVersion struct {
+	SemVer
+}
+
+func (g goVersion) Parse(s string) (semver.Version, error) {
+	if modsemver.Canonical(s) != s {
+		return semver.Version{}, fmt.Errorf("%s is not a canonical go version", s)
+	}
+	return g.SemVer.Parse(s)
+}
+
+// FindPreviousGoVersion finds the most recent tag which go accepts as a module version, to build pseudo-versions on.
+// Tags which are not canonical go versions are ignored.
+func FindPreviousGoVersion(r *repo.Repository) (version semver.Version, foundTag string, errReturn error) {
+	return FindPreviousVersionFromTagScheme(r, "", goVersion{})
+}
+
+// GoPseudoVersion returns the go pseudo-version for an untagged commit whose most recent previous version is given.
+// If there is no previous version, previous is nil and major (e.g. "v2", or "" for v0/v1 modules) is used instead.
+func GoPseudoVersion(major string, previous *semver.Version, commit *object.Commit) string {
+	older := ""
+	if previous != nil {
+		older = "v" + previous.String()
+	}
+
+	return module.PseudoVersion(major, older, commit.Committer.When, commit.Hash.String()[:12])
+}
@@ -0,0 +1,41 @@
+package versions
+
+import (
+	"github.com/Masterminds/semver"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGoPseudoVersion(t *testing.T) {
+	commit := &object.Commit{
+		Hash:      plumbing.NewHash("abcdef123456789012345678901234567890abcd"),
+		Committer: object.Signature{When: time.Date(2026, 10, 17, 8, 0, 0, 0, time.FixedZone("EDT", -4*60*60))},
+	}
+
+	tests := []struct {
+		name     string
+		major    string
+		previous string
+		want     string
+	}{
+		{"no tag", "", "", "v0.0.0-20261017120000-abcdef123456"},
+		{"no tag major", "v2", "", "v2.0.0-20261017120000-abcdef123456"},
+		{"release tag", "", "1.4.0", "v1.4.1-0.20261017120000-abcdef123456"},
+		{"short release tag", "", "0.2", "v0.2.1-0.20261017120000-abcdef123456"},
+		{"prerelease tag", "", "1.5.0-rc.1", "v1.5.0-rc.1.0.20261017120000-abcdef123456"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var previous *semver.Version
+			if tt.previous != "" {
+				var err error
+				previous, err = semver.NewVersion(tt.previous)
+				must(t, err)
+			}
+			assert.Equal(t, tt.want, GoPseudoVersion(tt.major, previous, commit))
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package versions
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Masterminds/semver"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scheme is a way of numbering versions.  Versions of every scheme are held as semver.Version, whose major, minor and
+// patch numbers are the first three segments of the version.
+type Scheme interface {
+	// Name is the name by which the scheme is selected
+	Name() string
+	// Parse interprets a version string (e.g. a tag, with or without a leading "v") in the scheme
+	Parse(s string) (semver.Version, error)
+	// Format writes the version the way the scheme spells it
+	Format(v semver.Version) string
+	// Next returns the version following previous for changes calling for the bump, made at the date.  BumpNone
+	// returns the previous version.
+	Next(previous semver.Version, bump Bump, date time.Time) (semver.Version, error)
+}
+
+// DefaultCalVerFormat is the format of calendar versions when none is given
+const DefaultCalVerFormat = "YYYY.MM.MICRO"
+
+// SchemeNames lists the names of the version schemes
+func SchemeNames() []string {
+	return []string{"semver", "calver"}
+}
+
+// NewScheme returns the named scheme.  The format applies only to calver, where "" means DefaultCalVerFormat.
+func NewScheme(name, format string) (Scheme, error) {
+	switch name {
+	case "", "semver":
+		return SemVer{}, nil
+	case "calver":
+		if format == "" {
+			format = DefaultCalVerFormat
+		}
+		return NewCalVer(format)
+	default:
+		return nil, fmt.Errorf("unknown version scheme %s", name)
+	}
+}
+
+// SemVer is semantic versioning, where the bump level decides which number is incremented
+type SemVer struct{}
+
+func (SemVer) Name() string {
+	return "semver"
+}
+
+func (SemVer) Parse(s string) (semver.Version, error) {
+	v, err := semver.NewVersion(s)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	return *v, nil
+}
+
+func (SemVer) Format(v semver.Version) string {
+	return v.String()
+}
+
+func (SemVer) Next(previous semver.Version, bump Bump, _ time.Time) (semver.Version, error) {
+	return bump.Apply(previous), nil
+}
+
+// calVerToken is an element of a calendar version format, as described at https://calver.org
+type calVerToken struct {
+	// width is the number of digits the value is padded to, 0 for none
+	width int
+	// value returns the value of the token at the date, given the year the date belongs to, and is nil for MICRO
+	value func(year int, t time.Time) int
+	// week is true for the ISO week tokens
+	week bool
+}
+
+var calVerTokens = map[string]calVerToken{
+	"YYYY":  {0, func(year int, _ time.Time) int { return year }, false},
+	"YY":    {0, func(year int, _ time.Time) int { return year - 2000 }, false},
+	"0Y":    {2, func(year int, _ time.Time) int { return year - 2000 }, false},
+	"MM":    {0, func(_ int, t time.Time) int { return int(t.Month()) }, false},
+	"0M":    {2, func(_ int, t time.Time) int { return int(t.Month()) }, false},
+	"WW":    {0, isoWeek, true},
+	"0W":    {2, isoWeek, true},
+	"DD":    {0, func(_ int, t time.Time) int { return t.Day() }, false},
+	"0D":    {2, func(_ int, t time.Time) int { return t.Day() }, false},
+	"MICRO": {0, nil, false},
+}
+
+func isoWeek(_ int, t time.Time) int {
+	_, week := t.ISOWeek()
+	return week
+}
+
+// CalVer is calendar versioning, where the version is the date of the release in the format, e.g. "YYYY.0M.MICRO",
+// and MICRO counts the releases made within the same period, starting again at 0 in each new period
+type CalVer struct {
+	format string
+	tokens []string
+	// weekly is true if the format has a week segment, so that the year is that of the ISO week, e.g. 2024-12-30 is in
+	// week 1 of 2025
+	weekly bool
+}
+
+// NewCalVer creates a calendar versioning scheme from a format of up to three "." separated tokens:  YYYY, YY, 0Y, MM,
+// 0M, WW, 0W (ISO week), DD, 0D and MICRO, which must come last
+func NewCalVer(format string) (*CalVer, error) {
+	tokens := strings.Split(format, ".")
+	if len(tokens) > 3 {
+		return nil, fmt.Errorf("calver format %s has more than 3 segments", format)
+	}
+
+	dated, weekly := false, false
+	for n, token := range tokens {
+		t, found := calVerTokens[token]
+		switch {
+		case !found:
+			return nil, fmt.Errorf("calver format %s: unknown segment %s", format, token)
+		case t.value == nil && n != len(tokens)-1:
+			return nil, fmt.Errorf("calver format %s: MICRO must be the last segment", format)
+		case t.value != nil:
+			dated = true
+		}
+		weekly = weekly || t.week
+	}
+
+	if !dated {
+		return nil, fmt.Errorf("calver format %s has no date segment", format)
+	}
+
+	return &CalVer{format: format, tokens: tokens, weekly: weekly}, nil
+}
+
+func (c *CalVer) Name() string {
+	return "calver"
+}
+
+// segments returns the first n segments of the version
+func segments(v semver.Version, n int) []int {
+	return []int{int(v.Major()), int(v.Minor()), int(v.Patch())}[:n]
+}
+
+// fromSegments builds the version with the segments and prerelease
+func fromSegments(values []int, prerelease string) (semver.Version, error) {
+	all := append(values, 0, 0, 0)
+	s := fmt.Sprintf("%d.%d.%d", all[0], all[1], all[2])
+	if prerelease != "" {
+		s += "-" + prerelease
+	}
+
+	v, err := semver.NewVersion(s)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	return *v, nil
+}
+
+func (c *CalVer) Parse(s string) (semver.Version, error) {
+	s = strings.TrimPrefix(s, "v")
+
+	if n := strings.Index(s, "+"); n >= 0 {
+		s = s[:n]
+	}
+
+	prerelease := ""
+	if n := strings.Index(s, "-"); n >= 0 {
+		s, prerelease = s[:n], s[n+1:]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != len(c.tokens) {
+		return semver.Version{}, fmt.Errorf("%s does not match calver format %s", s, c.format)
+	}
+
+	var values []int
+	for n, part := range parts {
+		width := calVerTokens[c.tokens[n]].width
+		value, err := strconv.Atoi(part)
+		switch {
+		case err != nil || value < 0:
+			return semver.Version{}, fmt.Errorf("%s does not match calver format %s", s, c.format)
+		case width > 0 && len(part) != width:
+			return semver.Version{}, fmt.Errorf("%s does not match calver format %s: %s must have %d digits", s, c.format, c.tokens[n], width)
+		case c.tokens[n] == "YYYY" && len(part) != 4:
+			return semver.Version{}, fmt.Errorf("%s does not match calver format %s: YYYY must have 4 digits", s, c.format)
+		}
+		values = append(values, value)
+	}
+
+	return fromSegments(values, prerelease)
+}
+
+func (c *CalVer) Format(v semver.Version) string {
+	var parts []string
+	for n, value := range segments(v, len(c.tokens)) {
+		parts = append(parts, fmt.Sprintf("%0*d", calVerTokens[c.tokens[n]].width, value))
+	}
+
+	s := strings.Join(parts, ".")
+	if v.Prerelease() != "" {
+		s += "-" + v.Prerelease()
+	}
+	if v.Metadata() != "" {
+		s += "+" + v.Metadata()
+	}
+	return s
+}
+
+// ErrNoMicro is returned when a release is needed in a period which already has one, but the format has no MICRO
+var ErrNoMicro = errors.New("the calver format has no MICRO segment to count a second release in the same period")
+
+func (c *CalVer) Next(previous semver.Version, bump Bump, date time.Time) (semver.Version, error) {
+	if bump == BumpNone {
+		return previous, nil
+	}
+
+	prior := segments(previous, len(c.tokens))
+
+	year := date.Year()
+	if c.weekly {
+		year, _ = date.ISOWeek()
+	}
+
+	var values []int
+	samePeriod := true
+	for n, token := range c.tokens {
+		t := calVerTokens[token]
+		if t.value == nil {
+			if samePeriod {
+				values = append(values, prior[n]+1)
+			} else {
+				values = append(values, 0)
+			}
+			continue
+		}
+
+		value := t.value(year, date)
+		if value != prior[n] {
+			samePeriod = false
+		}
+		values = append(values, value)
+	}
+
+	next, err := fromSegments(values, "")
+	if err != nil {
+		return next, err
+	}
+
+	if !next.GreaterThan(&previous) {
+		if samePeriod {
+			return next, fmt.Errorf("%s: %w", c.Format(previous), ErrNoMicro)
+		}
+		return next, fmt.Errorf("the date %s gives version %s, which is not after %s", date.Format("2006-01-02"), c.Format(next), c.Format(previous))
+	}
+
+	return next, nil
+}
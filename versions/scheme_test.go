@@ -0,0 +1,107 @@
+package versions
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNewCalVer(t *testing.T) {
+	tests := []struct {
+		format  string
+		wantErr bool
+	}{
+		{"YYYY.MM.MICRO", false},
+		{"YY.0W", false},
+		{"YYYY.0M.0D", false},
+		{"MICRO.YYYY", true},
+		{"YYYY.MM.DD.MICRO", true},
+		{"YYYY.QQ", true},
+		{"MICRO", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			_, err := NewCalVer(tt.format)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCalVer(t *testing.T) {
+	tests := []struct {
+		format   string
+		previous string
+		bump     Bump
+		date     string
+		want     string
+		wantErr  bool
+	}{
+		{"YYYY.MM.MICRO", "2024.5.0", BumpPatch, "2024-05-20", "2024.5.1", false},
+		{"YYYY.MM.MICRO", "2024.5.3", BumpMajor, "2024-06-01", "2024.6.0", false},
+		{"YYYY.MM.MICRO", "2024.5.3", BumpNone, "2024-06-01", "2024.5.3", false},
+		{"YYYY.0M.MICRO", "v2024.05.3", BumpMinor, "2024-05-02", "2024.05.4", false},
+		{"YY.0W", "24.05", BumpPatch, "2024-02-07", "24.06", false},
+		{"YY.0W", "24.06", BumpPatch, "2024-02-07", "", true},
+		{"YY.0W", "24.52", BumpPatch, "2024-12-30", "25.01", false},
+		{"YYYY.WW.MICRO", "2020.53.0", BumpPatch, "2021-01-03", "2020.53.1", false},
+		{"YYYY.MM.MICRO", "2024.5.3", BumpPatch, "2024-04-01", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.previous+" "+tt.date, func(t *testing.T) {
+			scheme, err := NewScheme("calver", tt.format)
+			must(t, err)
+
+			previous, err := scheme.Parse(tt.previous)
+			must(t, err)
+
+			got, err := scheme.Next(previous, tt.bump, date(tt.date))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, scheme.Format(got))
+			}
+		})
+	}
+
+	t.Run("no micro", func(t *testing.T) {
+		scheme, err := NewCalVer("YY.0W")
+		must(t, err)
+
+		previous, err := scheme.Parse("24.06")
+		must(t, err)
+
+		_, err = scheme.Next(previous, BumpPatch, date("2024-02-07"))
+		assert.True(t, errors.Is(err, ErrNoMicro))
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		scheme, err := NewCalVer("YYYY.0M.MICRO")
+		must(t, err)
+
+		_, err = scheme.Parse("2024.5.1")
+		assert.Error(t, err, "0M needs 2 digits")
+
+		_, err = scheme.Parse("1.2")
+		assert.Error(t, err, "too few segments")
+
+		v, err := scheme.Parse("2024.05.1-rc.1")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "2024.05.1-rc.1", scheme.Format(v))
+		}
+	})
+}